@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/gogitver/pkg/git"
+	"github.com/syncromatics/gogitver/pkg/updater"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Writes the version into the files listed under update-files in the settings",
+	Long: `Writes the calculated version into the version field of each file listed under update-files in
+the settings, leaving the rest of the file untouched. With --check no files are written and the
+command fails if any of them is out of date.`,
+	RunE:          runApply,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	addVersionFlags(applyCmd)
+	applyCmd.Flags().Bool("check", false, "fail if any file is out of date instead of writing it")
+
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	r, s := getRepoAndSettings(cmd)
+	if len(s.UpdateFiles) == 0 {
+		return errors.New("no files to update, list them under update-files in the settings")
+	}
+
+	info, err := getVersionFromFlags(cmd, r, s)
+	if err != nil {
+		return err
+	}
+
+	root, err := git.FindRepositoryRoot(cmd.Flag("path").Value.String())
+	if err != nil {
+		return err
+	}
+
+	check := getBoolFromFlag(cmd, "check")
+	results, err := updater.Apply(root, s.UpdateFiles, info.Version.String(), check)
+	if err != nil {
+		return err
+	}
+
+	outdated := 0
+	for _, result := range results {
+		switch {
+		case !result.Changed:
+			fmt.Printf("%s is up to date\n", result.Path)
+		case check:
+			outdated++
+			fmt.Printf("%s is out of date\n", result.Path)
+		default:
+			fmt.Printf("updated %s to %s\n", result.Path, info.Version)
+		}
+	}
+
+	if outdated > 0 {
+		return errors.Errorf("%d of %d files are out of date with version %s", outdated, len(results), info.Version)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/gogitver/pkg/git"
+	"gopkg.in/yaml.v2"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspects the settings used to calculate versions",
+	Long:  ``,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:           "validate",
+	Short:         "Validates the settings file",
+	Long:          ``,
+	RunE:          runConfigValidate,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:           "show",
+	Short:         "Prints the effective settings",
+	Long:          ``,
+	RunE:          runConfigShow,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{configValidateCmd, configShowCmd} {
+		addPathFlags(cmd)
+		addSettingsFlags(cmd)
+		configCmd.AddCommand(cmd)
+	}
+
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	_, err := getSettings(cmd)
+	if settingsErrors, ok := err.(git.SettingsErrors); ok {
+		for _, e := range settingsErrors {
+			fmt.Println(e)
+		}
+		return errors.New("settings are invalid")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("settings are valid")
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	s, err := getSettings(cmd)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "failed to write settings")
+	}
+
+	fmt.Print(string(out))
+	return nil
+}
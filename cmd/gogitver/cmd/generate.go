@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/gogitver/pkg/output"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generates source files that contain the version",
+	Long:  ``,
+}
+
+var generateGoCmd = &cobra.Command{
+	Use:   "go",
+	Short: "Generates a Go file with version constants",
+	Long: `Generates a Go file that declares the constants SemVer, Major, Minor, Patch, PreRelease, Commit,
+Branch and CommitDate. It can be run by go generate, e.g.
+
+//go:generate gogitver generate go --package version --out version_gen.go`,
+	RunE:          runGenerate(generateGo),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var generateCSharpCmd = &cobra.Command{
+	Use:           "csharp",
+	Short:         "Generates a C# AssemblyInfo file with the version attributes and constants",
+	Long:          ``,
+	RunE:          runGenerate(generateCSharp),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var generateJSONCmd = &cobra.Command{
+	Use:           "json",
+	Short:         "Generates a JSON file with the version variables",
+	Long:          ``,
+	RunE:          runGenerate(generateJSON),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{generateGoCmd, generateCSharpCmd, generateJSONCmd} {
+		addVersionFlags(cmd)
+		cmd.Flags().String("out", "", "the file to write, standard output if not given")
+		generateCmd.AddCommand(cmd)
+	}
+
+	generateGoCmd.Flags().String("package", "version", "the package of the generated file")
+	generateCSharpCmd.Flags().String("namespace", "", "the namespace of the GitVersionInformation class")
+
+	rootCmd.AddCommand(generateCmd)
+}
+
+// runGenerate returns a command that writes the file produced by generate
+func runGenerate(generate func(*cobra.Command, *output.Variables) ([]byte, error)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		r, s := getRepoAndSettings(cmd)
+		info, err := getVersionFromFlags(cmd, r, s)
+		if err != nil {
+			return err
+		}
+
+		content, err := generate(cmd, output.NewVariables(info))
+		if err != nil {
+			return err
+		}
+
+		return writeGeneratedFile(cmd.Flag("out").Value.String(), content)
+	}
+}
+
+func generateGo(cmd *cobra.Command, v *output.Variables) ([]byte, error) {
+	return output.GenerateGo(v, cmd.Flag("package").Value.String())
+}
+
+func generateCSharp(cmd *cobra.Command, v *output.Variables) ([]byte, error) {
+	return output.GenerateCSharp(v, cmd.Flag("namespace").Value.String())
+}
+
+func generateJSON(cmd *cobra.Command, v *output.Variables) ([]byte, error) {
+	return output.GenerateJSON(v)
+}
+
+// writeGeneratedFile writes content to out, or to standard output when out is empty. An unchanged
+// file is not written again so that build tools do not see it as modified.
+func writeGeneratedFile(out string, content []byte) error {
+	if out == "" {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+
+	existing, err := ioutil.ReadFile(out)
+	if err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+
+	err = ioutil.WriteFile(out, content, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "cannot write %s", out)
+	}
+	return nil
+}
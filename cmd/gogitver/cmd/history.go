@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/gogitver/pkg/git"
+)
+
+var historyCmd = &cobra.Command{
+	Use:           "history",
+	Short:         "Lists the version of each commit on master",
+	Long:          ``,
+	RunE:          runHistory,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var historyFormats = map[string]func(io.Writer, []*git.VersionHistoryEntry) error{
+	"table": writeHistoryTable,
+	"json":  writeHistoryJSON,
+	"csv":   writeHistoryCSV,
+}
+
+func init() {
+	addPathFlags(historyCmd)
+	addSettingsFlags(historyCmd)
+	historyCmd.Flags().BoolP("verbose", "v", false, "Show information about how the version was calculated")
+	historyCmd.Flags().String("since", "", "only list commits made on or after this date (YYYY-MM-DD or RFC 3339)")
+	historyCmd.Flags().Int("limit", 0, "only list this many of the most recent commits")
+	historyCmd.Flags().StringP("output", "o", "table", "the output format: table, json or csv")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	output := cmd.Flag("output").Value.String()
+	write, ok := historyFormats[output]
+	if !ok {
+		return errors.Errorf("unknown output format '%s'", output)
+	}
+
+	var since time.Time
+	if value := cmd.Flag("since").Value.String(); value != "" {
+		var err error
+		since, err = parseDate(value)
+		if err != nil {
+			return errors.Wrap(err, "invalid --since")
+		}
+	}
+
+	limit, err := strconv.Atoi(cmd.Flag("limit").Value.String())
+	if err != nil {
+		return errors.Wrap(err, "invalid --limit")
+	}
+
+	r, s := getRepoAndSettings(cmd)
+	history, err := git.GetVersionHistory(r, s, getBoolFromFlag(cmd, "verbose"))
+	if err != nil {
+		return err
+	}
+
+	filtered := []*git.VersionHistoryEntry{}
+	for _, entry := range history {
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+		if entry.Date.Before(since) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+
+	return write(os.Stdout, filtered)
+}
+
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return date, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+func writeHistoryTable(w io.Writer, history []*git.VersionHistoryEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tCOMMIT\tDATE\tSUBJECT")
+	for _, entry := range history {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Version, entry.Commit[:7], entry.Date.Format("2006-01-02 15:04"), entry.Subject)
+	}
+	return tw.Flush()
+}
+
+func writeHistoryJSON(w io.Writer, history []*git.VersionHistoryEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(history)
+}
+
+func writeHistoryCSV(w io.Writer, history []*git.VersionHistoryEntry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"version", "commit", "date", "subject"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range history {
+		err = cw.Write([]string{entry.Version, entry.Commit, entry.Date.Format(time.RFC3339), entry.Subject})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/gogitver/pkg/git"
+)
+
+const hookMarker = "# installed by gogitver"
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manages git hooks that check version bump keywords",
+	Long:  ``,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:           "install",
+	Short:         "Installs a commit-msg hook that runs gogitver lint-message",
+	Long:          ``,
+	RunE:          runHookInstall,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	addPathFlags(hookInstallCmd)
+	hookInstallCmd.Flags().Bool("force", false, "replace an existing commit-msg hook that was not installed by gogitver")
+	hookInstallCmd.Flags().Bool("require-bump", false, "reject commit messages without a version bump keyword")
+	hookInstallCmd.Flags().Bool("strict", false, "reject commit messages with warnings")
+
+	hookCmd.AddCommand(hookInstallCmd)
+	rootCmd.AddCommand(hookCmd)
+}
+
+func runHookInstall(cmd *cobra.Command, args []string) error {
+	hooks, err := git.HooksDir(repositoryPaths(cmd))
+	if err != nil {
+		return errors.Wrap(err, "cannot find the hooks directory of the repository")
+	}
+
+	hook := filepath.Join(hooks, "commit-msg")
+	existing, err := ioutil.ReadFile(hook)
+	if err == nil && !strings.Contains(string(existing), hookMarker) && !getBoolFromFlag(cmd, "force") {
+		return errors.Errorf("%s already exists, use --force to replace it", hook)
+	}
+
+	command := []string{"exec", "gogitver", "lint-message"}
+	for _, flag := range []string{"require-bump", "strict"} {
+		if getBoolFromFlag(cmd, flag) {
+			command = append(command, "--"+flag)
+		}
+	}
+	command = append(command, `"$1"`)
+
+	script := fmt.Sprintf("#!/bin/sh\n%s\n%s\n", hookMarker, strings.Join(command, " "))
+
+	err = os.MkdirAll(hooks, 0755)
+	if err != nil {
+		return errors.Wrap(err, "cannot create hooks directory")
+	}
+
+	err = ioutil.WriteFile(hook, []byte(script), 0755)
+	if err != nil {
+		return errors.Wrap(err, "cannot write commit-msg hook")
+	}
+
+	fmt.Printf("installed %s\n", hook)
+	return nil
+}
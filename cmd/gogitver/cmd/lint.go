@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/gogitver/pkg/lint"
+)
+
+var lintMessageCmd = &cobra.Command{
+	Use:           "lint-message <file>",
+	Short:         "Checks the version bump keywords in a commit message file",
+	Long:          ``,
+	Args:          cobra.ExactArgs(1),
+	RunE:          runLintMessage,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	addPathFlags(lintMessageCmd)
+	addSettingsFlags(lintMessageCmd)
+	lintMessageCmd.Flags().Bool("require-bump", false, "fail if the message does not contain a version bump keyword")
+	lintMessageCmd.Flags().Bool("strict", false, "fail on warnings as well as errors")
+
+	rootCmd.AddCommand(lintMessageCmd)
+}
+
+func runLintMessage(cmd *cobra.Command, args []string) error {
+	s, err := getSettings(cmd)
+	if err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return errors.Wrap(err, "cannot read commit message")
+	}
+
+	result, err := lint.LintMessage(s, lint.CleanMessage(string(content)), &lint.MessageOptions{
+		RequireBump: getBoolFromFlag(cmd, "require-bump"),
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, finding := range result.Findings {
+		fmt.Printf("gogitver: %s: %s\n", finding.Severity, finding.Message)
+		if finding.Severity == lint.SeverityError || getBoolFromFlag(cmd, "strict") {
+			failed = true
+		}
+	}
+
+	if failed {
+		return errors.New("commit message failed gogitver lint")
+	}
+	return nil
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Checks the version bump keywords of every commit in a range",
+	Long: `Checks the commits reachable from --to but not from --from, as listed by git log from..to,
+and reports the bump merging them into master would make.
+
+Exits with 0 when the range passes, 2 when a commit fails the lint and 1 when the range cannot be linted.`,
+	RunE:          runLint,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var lintFormats = map[string]func(io.Writer, *lint.RangeReport) error{
+	"text":  writeLintText,
+	"json":  writeLintJSON,
+	"sarif": writeLintSARIF,
+}
+
+func init() {
+	addPathFlags(lintCmd)
+	addSettingsFlags(lintCmd)
+	lintCmd.Flags().String("from", "origin/master", "the revision the range starts from, usually the target branch")
+	lintCmd.Flags().String("to", "HEAD", "the revision the range ends at")
+	lintCmd.Flags().Bool("require-bump", false, "fail if a commit does not contain a version bump keyword")
+	lintCmd.Flags().Bool("strict", false, "fail on warnings as well as errors")
+	lintCmd.Flags().StringP("output", "o", "text", "the output format: text, json or sarif")
+
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	output := cmd.Flag("output").Value.String()
+	write, ok := lintFormats[output]
+	if !ok {
+		return errors.Errorf("unknown output format '%s'", output)
+	}
+
+	r, s := getRepoAndSettings(cmd)
+	report, err := lint.LintRange(r, s, cmd.Flag("from").Value.String(), cmd.Flag("to").Value.String(), &lint.RangeOptions{
+		RequireBump: getBoolFromFlag(cmd, "require-bump"),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = write(os.Stdout, report)
+	if err != nil {
+		return err
+	}
+
+	if report.Errors > 0 || (getBoolFromFlag(cmd, "strict") && report.Warnings > 0) {
+		return &exitError{code: 2, message: fmt.Sprintf("commits failed gogitver lint with %d errors and %d warnings", report.Errors, report.Warnings)}
+	}
+	return nil
+}
+
+func writeLintText(w io.Writer, report *lint.RangeReport) error {
+	for _, commit := range report.Commits {
+		if len(commit.Findings) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s %s\n", commit.Commit[:7], commit.Subject)
+		for _, finding := range commit.Findings {
+			fmt.Fprintf(w, "  %s: %s (%s)\n", finding.Severity, finding.Message, finding.Rule)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d commits, %d errors, %d warnings, bump: %s\n", len(report.Commits), report.Errors, report.Warnings, report.Bump)
+	return err
+}
+
+func writeLintJSON(w io.Writer, report *lint.RangeReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool              `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]string `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// writeLintSARIF writes the report as a SARIF 2.1.0 log so that findings can be shown as annotations
+func writeLintSARIF(w io.Writer, report *lint.RangeReport) error {
+	results := []sarifResult{}
+	for _, commit := range report.Commits {
+		for _, finding := range commit.Findings {
+			results = append(results, sarifResult{
+				RuleID:  finding.Rule,
+				Level:   string(finding.Severity),
+				Message: sarifMessage{Text: fmt.Sprintf("%s %s: %s", commit.Commit[:7], commit.Subject, finding.Message)},
+				PartialFingerprints: map[string]string{
+					"commit/v1": commit.Commit + ":" + finding.Rule,
+				},
+				Properties: map[string]string{
+					"commit":  commit.Commit,
+					"subject": commit.Subject,
+				},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{Driver: sarifDriver{
+					Name:           "gogitver",
+					InformationURI: "https://github.com/syncromatics/gogitver",
+				}},
+				Results:    results,
+				Properties: map[string]interface{}{"bump": report.Bump},
+			},
+		},
+	})
+}
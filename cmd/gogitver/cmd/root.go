@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/syncromatics/gogitver/pkg/git"
+	"github.com/syncromatics/gogitver/pkg/output"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
@@ -30,13 +34,18 @@ var prereleaseCmd = &cobra.Command{
 func init() {
 	var cmds = [2]*cobra.Command{rootCmd, prereleaseCmd}
 	for _, cmd := range cmds {
-		cmd.Flags().String("path", ".", "the path to the git repository")
-		cmd.Flags().String("settings", "./.gogitver.yaml", "the file that contains the settings")
+		addPathFlags(cmd)
+		addSettingsFlags(cmd)
 		cmd.Flags().Bool("trim-branch-prefix", false, "Trim branch prefixes feature/ and hotfix/ from prerelease label")
 		cmd.Flags().BoolP("verbose", "v", false, "Show information about how the version was calculated")
 	}
 
 	rootCmd.Flags().Bool("forbid-behind-master", false, "error if the current branch's calculated version is behind the calculated version of refs/heads/master")
+	rootCmd.Flags().String("ref", "", "calculate the version of this commit, branch or tag instead of HEAD")
+	rootCmd.Flags().Bool("fail-on-dirty", false, "error if the working tree has uncommitted changes")
+	rootCmd.Flags().StringP("output", "o", "version", fmt.Sprintf("the output format: %s", strings.Join(output.FormatNames(), ", ")))
+	rootCmd.Flags().String("prefix", output.DefaultPrefix, "the prefix of variable names in the env, dotenv, make and powershell output formats")
+	rootCmd.Flags().StringArray("ldflags-var", []string{}, "a Go variable set by the ldflags output format, e.g. main.version or main.commit=commit (default main.version)")
 
 	rootCmd.AddCommand(prereleaseCmd)
 }
@@ -45,36 +54,172 @@ func init() {
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
+		if exit, ok := err.(*exitError); ok {
+			os.Exit(exit.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError is returned by commands that exit with a code other than 1
+type exitError struct {
+	code    int
+	message string
+}
+
+func (e *exitError) Error() string {
+	return e.message
+}
+
 func getRepoAndSettings(cmd *cobra.Command) (*gogit.Repository, *git.Settings) {
-	f := cmd.Flag("path")
+	r, err := openRepository(cmd)
+	if err != nil {
+		panic(err)
+	}
+
+	s, err := getSettings(cmd)
+	if err != nil {
+		panic(err)
+	}
+
+	return r, s
+}
+
+// addPathFlags adds the flags that locate the repository
+func addPathFlags(cmd *cobra.Command) {
+	cmd.Flags().String("path", ".", "the path to the git repository, its working tree or any directory within it")
+	cmd.Flags().String("git-dir", "", "the git directory of the repository, with --path as its working tree if given")
+}
+
+// openRepository opens the repository at --path, or the git directory given by --git-dir
+func openRepository(cmd *cobra.Command) (*gogit.Repository, error) {
+	return git.OpenRepository(repositoryPaths(cmd))
+}
+
+// repositoryPaths returns the path and git directory given by the flags added by addPathFlags. With
+// --git-dir the path is only used as the working tree when --path is given.
+func repositoryPaths(cmd *cobra.Command) (string, string) {
+	path := cmd.Flag("path")
+	gitDir := cmd.Flag("git-dir").Value.String()
+	if gitDir != "" && !path.Changed {
+		return "", gitDir
+	}
+
+	return path.Value.String(), gitDir
+}
+
+// isBare reports whether the repository has no working tree
+func isBare(r *gogit.Repository) bool {
+	_, err := r.Worktree()
+	return err == gogit.ErrIsBareRepository
+}
+
+func getSettings(cmd *cobra.Command) (*git.Settings, error) {
+	layers := &git.SettingsLayers{
+		Environ:   os.Environ(),
+		Overrides: map[string]string{},
+	}
+
+	global, err := globalSettingsPath()
+	if err == nil {
+		file, err := readSettingsFile(global, false)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			layers.Files = append(layers.Files, file)
+		}
+	}
+
+	file, err := getRepositorySettingsFile(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if file != nil {
+		layers.Files = append(layers.Files, file)
+	}
+
+	for _, key := range git.SettingsKeys() {
+		f := cmd.Flag(key)
+		if f != nil && f.Changed {
+			layers.Overrides[key] = f.Value.String()
+		}
+	}
+
+	return git.LoadSettings(layers)
+}
+
+// getRepositorySettingsFile reads the settings file given by --settings, which defaults to the
+// one in the root of the repository at --path. With --settings-ref the file is read from that
+// revision instead of the working tree, and a bare repository reads it from HEAD.
+func getRepositorySettingsFile(cmd *cobra.Command) (*git.SettingsFile, error) {
+	path := cmd.Flag("path").Value.String()
 	sf := cmd.Flag("settings")
+	ref := cmd.Flag("settings-ref").Value.String()
 
-	var s *git.Settings
-	_, err := os.Stat(sf.Value.String())
-	if sf.Changed || err == nil {
-		r, err := os.Open(sf.Value.String())
+	if ref == "" && !sf.Changed {
+		r, err := openRepository(cmd)
+		if err == nil && isBare(r) {
+			ref = "HEAD"
+		}
+	}
+
+	if ref != "" {
+		r, err := openRepository(cmd)
 		if err != nil {
-			panic(errors.Wrap(err, "cannot open settings file"))
+			return nil, errors.Wrap(err, "cannot open repository to read settings")
 		}
 
-		s, err = git.GetSettingsFromFile(r)
+		name := git.SettingsFileName
+		if sf.Changed {
+			name = sf.Value.String()
+		}
+		return git.GetSettingsFileFromRevision(r, ref, name)
+	}
+
+	if sf.Changed {
+		return readSettingsFile(sf.Value.String(), true)
+	}
+
+	root, err := git.FindRepositoryRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	return readSettingsFile(filepath.Join(root, git.SettingsFileName), false)
+}
+
+// globalSettingsPath returns the location of the user's settings file, which is overridden by the repository's.
+func globalSettingsPath() (string, error) {
+	configHome, ok := os.LookupEnv("XDG_CONFIG_HOME")
+	if !ok || configHome == "" {
+		home, err := os.UserHomeDir()
 		if err != nil {
-			panic(err)
+			return "", err
 		}
-	} else {
-		s = git.GetDefaultSettings()
+		configHome = filepath.Join(home, ".config")
 	}
 
-	r, err := gogit.PlainOpen(f.Value.String())
+	return filepath.Join(configHome, "gogitver.yaml"), nil
+}
+
+func readSettingsFile(path string, required bool) (*git.SettingsFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return nil, nil
+	}
 	if err != nil {
-		panic(err)
+		return nil, errors.Wrap(err, "cannot open settings file")
 	}
 
-	return r, s
+	return &git.SettingsFile{Name: path, Content: content}, nil
+}
+
+func addSettingsFlags(cmd *cobra.Command) {
+	cmd.Flags().String("settings", "", "the file that contains the settings (default \".gogitver.yaml\" in the repository root)")
+	cmd.Flags().String("settings-ref", "", "read the settings file from this revision instead of the working tree")
+	for _, key := range git.SettingsKeys() {
+		cmd.Flags().String(key, "", fmt.Sprintf("override the %s setting", key))
+	}
 }
 
 func getBoolFromFlag(cmd *cobra.Command, flagName string) bool {
@@ -88,9 +233,11 @@ func getBoolFromFlag(cmd *cobra.Command, flagName string) bool {
 func getBranchSettings(cmd *cobra.Command) *git.BranchSettings {
 	fbm := getBoolFromFlag(cmd, "forbid-behind-master")
 	trimPrefix := getBoolFromFlag(cmd, "trim-branch-prefix")
+	failOnDirty := getBoolFromFlag(cmd, "fail-on-dirty")
 	return &git.BranchSettings{
 		ForbidBehindMaster: fbm,
 		TrimBranchPrefix:   trimPrefix,
+		FailOnDirty:        failOnDirty,
 	}
 }
 
@@ -102,13 +249,61 @@ func runRoot(cmd *cobra.Command, args []string) {
 		log.SetFlags(0)
 	}
 
+	format, err := output.GetFormat(cmd.Flag("output").Value.String())
+	if err != nil {
+		panic(err)
+	}
+
+	ldflagsVars, err := cmd.Flags().GetStringArray("ldflags-var")
+	if err != nil {
+		panic(err)
+	}
+
 	branchSettings := getBranchSettings(cmd)
-	version, err := git.GetCurrentVersion(r, s, branchSettings, v)
+	info, err := getVersion(cmd, r, s, branchSettings, v)
+	if err != nil {
+		panic(err)
+	}
+
+	err = format(os.Stdout, output.NewVariables(info), &output.Options{
+		Prefix:      cmd.Flag("prefix").Value.String(),
+		DockerTags:  &s.DockerTags,
+		LdflagsVars: ldflagsVars,
+	})
 	if err != nil {
 		panic(err)
 	}
+}
+
+// addVersionFlags adds the flags of the root command that control how the version is calculated
+// to a subcommand that uses the version
+func addVersionFlags(cmd *cobra.Command) {
+	addPathFlags(cmd)
+	addSettingsFlags(cmd)
+	cmd.Flags().Bool("trim-branch-prefix", false, "Trim branch prefixes feature/ and hotfix/ from prerelease label")
+	cmd.Flags().Bool("forbid-behind-master", false, "error if the current branch's calculated version is behind the calculated version of refs/heads/master")
+	cmd.Flags().String("ref", "", "calculate the version of this commit, branch or tag instead of HEAD")
+	cmd.Flags().Bool("fail-on-dirty", false, "error if the working tree has uncommitted changes")
+	cmd.Flags().BoolP("verbose", "v", false, "Show information about how the version was calculated")
+}
+
+// getVersionFromFlags calculates the version for a command with the flags added by addVersionFlags
+func getVersionFromFlags(cmd *cobra.Command, r *gogit.Repository, s *git.Settings) (*git.VersionInfo, error) {
+	verbose := getBoolFromFlag(cmd, "verbose")
+	if verbose {
+		log.SetFlags(0)
+	}
+
+	return getVersion(cmd, r, s, getBranchSettings(cmd), verbose)
+}
+
+func getVersion(cmd *cobra.Command, r *gogit.Repository, s *git.Settings, branchSettings *git.BranchSettings, verbose bool) (*git.VersionInfo, error) {
+	ref := cmd.Flag("ref").Value.String()
+	if ref != "" {
+		return git.GetVersionInfoAtRevision(r, ref, s, branchSettings, verbose)
+	}
 
-	fmt.Println(version)
+	return git.GetCurrentVersionInfo(r, s, branchSettings, verbose)
 }
 
 func runPrerelease(cmd *cobra.Command, args []string) {
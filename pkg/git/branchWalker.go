@@ -2,7 +2,6 @@ package git
 
 import (
 	"log"
-	"regexp"
 	"strings"
 
 	"gopkg.in/src-d/go-git.v4/plumbing"
@@ -20,17 +19,28 @@ type branchWalker struct {
 	settings   *Settings
 	isMaster   bool
 	endHash    string
+	mainline   map[string]bool
 	verbose    bool
 
+	// branch holds the commits of a branch that are not in the history of the mainline it forked
+	// from. When it is set, neither the walk nor merges on the branch count commits outside it, even
+	// after the mainline has been merged into the branch.
+	branch map[string]bool
+
+	// throughTags continues the walk past tagged commits instead of stopping at the first one
+	throughTags bool
+
 	visited            map[string]bool
-	commitsToReconcile map[string]*gitVersion
+	commitsToReconcile []*gitVersion
 }
 
 type versionHolder struct {
 	versionMap []*gitVersion
 }
 
-func newBranchWalker(repository *git.Repository, head *object.Commit, tagMap map[string]string, settings *Settings, isMaster bool, endHash string, verbose bool) *branchWalker {
+// newBranchWalker creates a walker over the history of head. Commits in mainline have already been
+// accounted for by the mainline walk and are never counted again; it is nil for the mainline itself.
+func newBranchWalker(repository *git.Repository, head *object.Commit, tagMap map[string]string, settings *Settings, isMaster bool, endHash string, mainline map[string]bool, verbose bool) *branchWalker {
 	return &branchWalker{
 		repository:         repository,
 		head:               head,
@@ -38,8 +48,9 @@ func newBranchWalker(repository *git.Repository, head *object.Commit, tagMap map
 		tagMap:             tagMap,
 		isMaster:           isMaster,
 		endHash:            endHash,
+		mainline:           mainline,
 		visited:            make(map[string]bool),
-		commitsToReconcile: make(map[string]*gitVersion),
+		commitsToReconcile: []*gitVersion{},
 		verbose:            verbose,
 	}
 }
@@ -50,45 +61,38 @@ func (b *branchWalker) GetVersion() (*semver.Version, error) {
 		return nil, err
 	}
 
-	var baseVersion *semver.Version
-	index := len(versionMap) - 1
-	v := versionMap[index]
-	if v.IsSolid {
-		baseVersion = v.Name
-		index--
-	} else {
-		baseVersion, err = semver.NewVersion("0.0.0")
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if index < 0 {
-		return baseVersion, nil
-	}
-
-	if b.verbose {
-		log.Printf("[%s] %s", v.Commit, baseVersion.String())
-	}
+	return b.getVersions(versionMap)[0], nil
+}
 
-	for ; index >= 0; index-- {
+// getVersions returns the version of every entry in a mainline version map in a single pass from
+// the oldest entry to the newest. The versions are ordered like the map, newest first.
+func (b *branchWalker) getVersions(versionMap []*gitVersion) []*semver.Version {
+	versions := make([]*semver.Version, len(versionMap))
+	version := semver.Version{}
+	for index := len(versionMap) - 1; index >= 0; index-- {
 		v := versionMap[index]
 		switch {
+		case v.IsSolid:
+			version = *v.Name
 		case v.MajorBump:
-			baseVersion.BumpMajor()
+			version.BumpMajor()
 		case v.MinorBump:
-			baseVersion.BumpMinor()
+			version.BumpMinor()
 		case v.PatchBump:
-			baseVersion.BumpPatch()
+			version.BumpPatch()
+		case v.NoBump, v.Ignored: // these commits are walked through without bumping the version
 		default: // every commit in master has at least a patch bump
-			baseVersion.BumpPatch()
+			version.BumpPatch()
 		}
 		if b.verbose {
-			log.Printf("[%s] %s", v.Commit, baseVersion.String())
+			log.Printf("[%s] %s", v.Commit, version.String())
 		}
+
+		current := version
+		versions[index] = &current
 	}
 
-	return baseVersion, nil
+	return versions
 }
 
 func (b *branchWalker) GetVersionMap() ([]*gitVersion, error) {
@@ -96,83 +100,91 @@ func (b *branchWalker) GetVersionMap() ([]*gitVersion, error) {
 		versionMap: []*gitVersion{},
 	}
 
-	err := b.walkVersion(b.head, &versionMap, false)
+	err := b.walkVersion(b.head, &versionMap)
 	if err != nil {
 		return nil, err
 	}
 
-	if b.isMaster {
-		for hash, version := range b.commitsToReconcile {
-			err = b.reconcileCommit(hash, version)
-			if err != nil {
-				return nil, err
-			}
+	// merges are reconciled oldest first so that commits reachable from more than one merge
+	// are attributed to the earliest merge that brought them in
+	for i := len(b.commitsToReconcile) - 1; i >= 0; i-- {
+		err = b.reconcileCommit(b.commitsToReconcile[i])
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	return versionMap.versionMap, nil
 }
 
-func (b *branchWalker) walkVersion(ref *object.Commit, version *versionHolder, tilVisited bool) error {
-	if _, visited := b.visited[ref.Hash.String()]; tilVisited && visited {
-		return nil
-	}
+// walkVersion follows the first parent of each commit starting at ref, appending one entry
+// per commit to version until it reaches a tag, the end hash, a mainline commit or a root commit. Merge commits
+// are queued to be reconciled once the walk is complete.
+func (b *branchWalker) walkVersion(ref *object.Commit, version *versionHolder) error {
+	for ref != nil {
+		b.visited[ref.Hash.String()] = true
 
-	b.visited[ref.Hash.String()] = true
+		tag, ok := b.tagMap[ref.Hash.String()]
+		if ok {
+			tagVersion, err := parseTag(tag)
+			if err != nil {
+				return err
+			}
+			version.versionMap = append(version.versionMap, &gitVersion{IsSolid: true, Name: tagVersion, Commit: ref.Hash.String()})
+			if !b.throughTags {
+				return nil
+			}
 
-	tag, ok := b.tagMap[ref.Hash.String()]
-	if ok {
-		tagVersion, err := parseTag(tag)
-		if err != nil {
-			return err
+			ref = b.nextParent(ref)
+			continue
 		}
-		version.versionMap = append(version.versionMap, &gitVersion{IsSolid: true, Name: tagVersion, Commit: ref.Hash.String()})
-		return nil
-	}
 
-	parents := ref.NumParents()
-	if parents > 1 {
-		versionToReconcile := gitVersion{IsSolid: false, Commit: ref.Hash.String()}
-		version.versionMap = append(version.versionMap, &versionToReconcile)
+		// an ignored merge does not bump the version itself, but the commits it merged still do
+		v := b.getCommitVersion(ref)
+		if ref.NumParents() > 1 {
+			b.commitsToReconcile = append(b.commitsToReconcile, v)
+		}
+		version.versionMap = append(version.versionMap, v)
 
-		b.commitsToReconcile[ref.Hash.String()] = &versionToReconcile
-		return b.checkWalkParent(ref, version, tilVisited)
+		ref = b.nextParent(ref)
 	}
 
-	matched, err := regexp.MatchString(b.settings.MajorPattern, ref.Message)
-	if err != nil {
-		return err
-	}
-	if matched {
-		version.versionMap = append(version.versionMap, &gitVersion{IsSolid: false, MajorBump: true, Commit: ref.Hash.String()})
-		return b.checkWalkParent(ref, version, tilVisited)
-	}
+	return nil
+}
 
-	matched, err = regexp.MatchString(b.settings.MinorPattern, ref.Message)
-	if err != nil {
-		return err
-	}
-	if matched {
-		version.versionMap = append(version.versionMap, &gitVersion{IsSolid: false, MinorBump: true, Commit: ref.Hash.String()})
-		return b.checkWalkParent(ref, version, tilVisited)
+func (b *branchWalker) getCommitVersion(ref *object.Commit) *gitVersion {
+	if b.settings.Ignore.ignores(ref) {
+		return &gitVersion{IsSolid: false, Ignored: true, Commit: ref.Hash.String()}
 	}
 
-	matched, err = regexp.MatchString(b.settings.PatchPattern, ref.Message)
-	if err != nil {
-		return err
+	return b.getMessageVersion(ref)
+}
+
+func (b *branchWalker) getMessageVersion(ref *object.Commit) *gitVersion {
+	version := &gitVersion{IsSolid: false, Commit: ref.Hash.String()}
+
+	bumps := b.settings.matchBumps(ref.Message)
+	if len(bumps) == 0 {
+		return version
 	}
-	if matched {
-		version.versionMap = append(version.versionMap, &gitVersion{IsSolid: false, PatchBump: true, Commit: ref.Hash.String()})
-		return b.checkWalkParent(ref, version, tilVisited)
+
+	switch bumps[0] {
+	case BumpMajor:
+		version.MajorBump = true
+	case BumpMinor:
+		version.MinorBump = true
+	case BumpPatch:
+		version.PatchBump = true
+	case BumpNone:
+		version.NoBump = true
 	}
 
-	version.versionMap = append(version.versionMap, &gitVersion{IsSolid: false, Commit: ref.Hash.String()})
-	return b.checkWalkParent(ref, version, tilVisited)
+	return version
 }
 
-func (b *branchWalker) checkWalkParent(ref *object.Commit, version *versionHolder, tilVisited bool) error {
-	parents := ref.NumParents()
-	if parents == 0 {
+// nextParent returns the first parent of ref, or nil when the walk should stop there.
+func (b *branchWalker) nextParent(ref *object.Commit) *object.Commit {
+	if ref.NumParents() == 0 {
 		return nil
 	}
 
@@ -181,15 +193,28 @@ func (b *branchWalker) checkWalkParent(ref *object.Commit, version *versionHolde
 		return nil
 	}
 
-	if parent.Hash.String() == b.endHash {
+	hash := parent.Hash.String()
+	if hash == b.endHash || b.mainline[hash] || !b.onBranch(hash) {
 		return nil
 	}
 
-	return b.walkVersion(parent, version, tilVisited)
+	return parent
+}
+
+// onBranch reports whether a commit may be counted by the walk: always on the mainline, and on a
+// branch only when the commit is not in the history of the mainline.
+func (b *branchWalker) onBranch(hash string) bool {
+	return b.branch == nil || b.branch[hash]
 }
 
-func (b *branchWalker) reconcileCommit(hash string, version *gitVersion) error {
-	commit, err := b.repository.CommitObject(plumbing.NewHash(hash))
+// reconcileCommit determines the bump of a merge commit from its own message and from every
+// commit it merged in. The merged commits are those reachable from the merged side but not from
+// the first parent and, on a branch, not from the mainline. They are walked through all parents
+// until reaching commits that were already walked, either on the first-parent history or by
+// reconciling an earlier merge, commits that are tagged or, off the mainline, commits that belong
+// to the mainline.
+func (b *branchWalker) reconcileCommit(version *gitVersion) error {
+	commit, err := b.repository.CommitObject(plumbing.NewHash(version.Commit))
 	if err != nil {
 		return errors.Wrap(err, "failed to get commit in reconcile")
 	}
@@ -199,40 +224,106 @@ func (b *branchWalker) reconcileCommit(hash string, version *gitVersion) error {
 		return nil
 	}
 
+	first, err := commit.Parent(0)
+	if err != nil {
+		return errors.Wrap(err, "failed to get parent in reconcile")
+	}
+
 	versionMap := versionHolder{
 		versionMap: []*gitVersion{},
 	}
-	for i := 1; i < numParents; i++ {
-		parentToWalk, err := commit.Parent(i)
+
+	merged := map[string]bool{}
+	toWalk := []*object.Commit{}
+	for i := numParents - 1; i >= 1; i-- {
+		parent, err := commit.Parent(i)
 		if err != nil {
 			return errors.Wrap(err, "failed to get parent in reconcile")
 		}
+		toWalk = append(toWalk, parent)
 
-		err = b.walkVersion(parentToWalk, &versionMap, true)
+		commits, err := commitsBetween(parent, first)
 		if err != nil {
-			return err
+			return errors.Wrap(err, "failed to find the merged commits in reconcile")
+		}
+		for _, c := range commits {
+			merged[c.Hash.String()] = true
 		}
 	}
 
-	var hasMajor, hasMinor bool
-	for _, bump := range versionMap.versionMap {
+	for len(toWalk) > 0 {
+		ref := toWalk[len(toWalk)-1]
+		toWalk = toWalk[:len(toWalk)-1]
+
+		hash := ref.Hash.String()
+		if !merged[hash] || !b.onBranch(hash) || b.visited[hash] || b.mainline[hash] {
+			continue
+		}
+		b.visited[hash] = true
+
+		if _, tagged := b.tagMap[hash]; tagged {
+			continue
+		}
+
+		versionMap.versionMap = append(versionMap.versionMap, b.getCommitVersion(ref))
+
+		for i := ref.NumParents() - 1; i >= 0; i-- {
+			parent, err := ref.Parent(i)
+			if err != nil {
+				continue // the parent is missing from a shallow clone
+			}
+			toWalk = append(toWalk, parent)
+		}
+	}
+
+	b.combineBumps(version, versionMap.versionMap)
+	return nil
+}
+
+// combineBumps sets the bump of version to the highest bump among its own message and the merged
+// commits. When nothing asks for a bump explicitly the mainline falls back to a patch bump, unless
+// every merged commit opted out of a bump or was ignored, or the merge itself is ignored and
+// merged nothing else.
+func (b *branchWalker) combineBumps(version *gitVersion, merged []*gitVersion) {
+	ignored := version.Ignored
+
+	// the merge commit's own message counts alongside the merged commits, and the highest bump wins
+	hasMajor, hasMinor, hasPatch, hasNoBump := version.MajorBump, version.MinorBump, version.PatchBump, version.NoBump
+	hasBump := version.PatchBump
+	for _, bump := range merged {
 		if bump.MajorBump {
 			hasMajor = true
 		}
 		if bump.MinorBump {
 			hasMinor = true
 		}
+		if bump.PatchBump {
+			hasPatch = true
+		}
+		if bump.NoBump {
+			hasNoBump = true
+		}
+		if !bump.NoBump && !bump.Ignored {
+			hasBump = true
+		}
 	}
 
-	if hasMajor {
+	version.MajorBump, version.MinorBump, version.PatchBump, version.NoBump, version.Ignored = false, false, false, false, false
+	switch {
+	case hasMajor:
 		version.MajorBump = true
-	} else if hasMinor {
+	case hasMinor:
 		version.MinorBump = true
-	} else {
+	case hasPatch:
+		version.PatchBump = true
+	case !b.isMaster: // off the mainline only explicit bumps count
+	case !hasBump && hasNoBump: // merging only commits that opted out of a bump
+		version.NoBump = true
+	case !hasBump && (len(merged) > 0 || ignored): // merging only ignored commits
+		version.Ignored = true
+	default:
 		version.PatchBump = true
 	}
-
-	return nil
 }
 
 func parseTag(tag string) (*semver.Version, error) { // TODO: Support ignoring invalid semver tags
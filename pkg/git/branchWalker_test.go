@@ -0,0 +1,128 @@
+package git_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+
+	igit "github.com/syncromatics/gogitver/pkg/git"
+)
+
+func BenchmarkGetCurrentVersion(b *testing.B) {
+	for _, size := range []int{1000, 10000, 50000} {
+		b.Run(fmt.Sprintf("%d-commits", size), func(b *testing.B) {
+			repository := generateRepository(b, size)
+			settings := igit.GetDefaultSettings()
+			branchSettings := &igit.BranchSettings{
+				IgnoreEnvVars: true,
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetCurrentVersionWithSettingsFile(b *testing.B) {
+	repository := generateRepository(b, 10000)
+	settings, err := igit.GetSettingsFromFile(strings.NewReader(`
+major-version-bump-message: '(major|breaking)(\(.*\))?!?:'
+minor-version-bump-message: '(feat|feature|minor)(\(.*\))?:'
+patch-version-bump-message: '(patch|fix)(\(.*\))?:'
+`))
+	if err != nil {
+		b.Fatal(err)
+	}
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// generateRepository writes a bare in-memory repository whose master branch has the given
+// number of first-parent commits. Every 50th commit merges a short side branch so the
+// benchmark also covers reconciliation.
+func generateRepository(b *testing.B, commits int) *git.Repository {
+	storage := memory.NewStorage()
+	repository, err := git.Init(storage, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	treeHash := storeObject(b, storage, &object.Tree{})
+	messages := []string{
+		"some text\n",
+		"(+semver: fix) a patch commit\n",
+		"(+semver: feature) a minor commit\n",
+		"some more text\n",
+	}
+
+	var head plumbing.Hash
+	for i := 0; i < commits; i++ {
+		parents := []plumbing.Hash{}
+		if i > 0 {
+			parents = append(parents, head)
+		}
+
+		if i > 0 && i%50 == 0 {
+			side := head
+			for j := 0; j < 3; j++ {
+				side = storeCommit(b, storage, treeHash, messages[j], side)
+			}
+			parents = append(parents, side)
+		}
+
+		head = storeCommit(b, storage, treeHash, messages[i%len(messages)], parents...)
+	}
+
+	err = storage.SetReference(plumbing.NewHashReference(plumbing.ReferenceName("refs/heads/master"), head))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return repository
+}
+
+func storeCommit(b *testing.B, storage *memory.Storage, treeHash plumbing.Hash, message string, parents ...plumbing.Hash) plumbing.Hash {
+	return storeObject(b, storage, &object.Commit{
+		Author:       *defaultSignature(),
+		Committer:    *defaultSignature(),
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	})
+}
+
+func storeObject(b *testing.B, storage *memory.Storage, o interface {
+	Encode(plumbing.EncodedObject) error
+}) plumbing.Hash {
+	obj := storage.NewEncodedObject()
+	err := o.Encode(obj)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	hash, err := storage.SetEncodedObject(obj)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return hash
+}
@@ -0,0 +1,76 @@
+package git
+
+// Bump is the change to a version that a commit message asks for. Bumps are ordered so that a
+// greater bump takes precedence over a lesser one.
+type Bump int
+
+const (
+	// BumpDefault is the bump of a message without a keyword: a patch bump on master and none elsewhere
+	BumpDefault Bump = iota
+	// BumpNone is the bump of a message that matches the no bump pattern
+	BumpNone
+	// BumpPatch is the bump of a message that matches the patch pattern
+	BumpPatch
+	// BumpMinor is the bump of a message that matches the minor pattern
+	BumpMinor
+	// BumpMajor is the bump of a message that matches the major pattern
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpNone:
+		return "none"
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "default"
+	}
+}
+
+// GetMessageBumps returns every bump whose pattern matches the message, greatest first
+func (s *Settings) GetMessageBumps(message string) ([]Bump, error) {
+	c, err := s.compiled()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.matchBumps(message), nil
+}
+
+// matchBumps is GetMessageBumps for settings that are already compiled.
+func (s *Settings) matchBumps(message string) []Bump {
+	bumps := []Bump{}
+	if s.majorRegexp.MatchString(message) {
+		bumps = append(bumps, BumpMajor)
+	}
+	if s.minorRegexp.MatchString(message) {
+		bumps = append(bumps, BumpMinor)
+	}
+	if s.patchRegexp.MatchString(message) {
+		bumps = append(bumps, BumpPatch)
+	}
+	if s.noBumpRegexp != nil && s.noBumpRegexp.MatchString(message) {
+		bumps = append(bumps, BumpNone)
+	}
+
+	return bumps
+}
+
+// GetMessageBump returns the bump a commit message asks for, which is the greatest bump whose
+// pattern matches it
+func (s *Settings) GetMessageBump(message string) (Bump, error) {
+	bumps, err := s.GetMessageBumps(message)
+	if err != nil {
+		return BumpDefault, err
+	}
+
+	if len(bumps) == 0 {
+		return BumpDefault, nil
+	}
+	return bumps[0], nil
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// The dirty-marker setting selects how a version calculated from a dirty working tree is marked
+const (
+	DirtyMarkerNone       = "none"
+	DirtyMarkerPrerelease = "prerelease"
+	DirtyMarkerMetadata   = "metadata"
+)
+
+const dirtyIdentifier = "dirty"
+
+// IsDirty reports whether the working tree of a repository has changes that are not committed.
+// Untracked files are not counted, the same as git describe --dirty. A bare repository is never dirty.
+func IsDirty(r *git.Repository) (bool, error) {
+	w, err := r.Worktree()
+	if err == git.ErrIsBareRepository {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "cannot open the working tree")
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot get the status of the working tree")
+	}
+
+	for _, file := range status {
+		if isChanged(file.Staging) || isChanged(file.Worktree) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isChanged(code git.StatusCode) bool {
+	return code != git.Unmodified && code != git.Untracked
+}
+
+// checkDirty marks the version when the working tree is dirty and the settings ask for a marker,
+// or fails when the branch settings forbid a dirty working tree. The status of the working tree is
+// only read when one of them is set.
+func (i *VersionInfo) checkDirty(r *git.Repository, settings *Settings, branchSettings *BranchSettings) error {
+	marker := settings.DirtyMarker
+	if (marker == "" || marker == DirtyMarkerNone) && !branchSettings.FailOnDirty {
+		return nil
+	}
+
+	dirty, err := IsDirty(r)
+	if err != nil {
+		return err
+	}
+	i.Dirty = dirty
+
+	if !dirty {
+		return nil
+	}
+
+	if branchSettings.FailOnDirty {
+		return errors.New("the working tree has uncommitted changes")
+	}
+
+	markDirty(i.Version, marker)
+	return nil
+}
+
+// markDirty appends the dirty identifier to the prerelease or build metadata of a version
+func markDirty(version *semver.Version, marker string) {
+	switch marker {
+	case DirtyMarkerPrerelease:
+		version.PreRelease = semver.PreRelease(appendIdentifier(string(version.PreRelease), dirtyIdentifier))
+	case DirtyMarkerMetadata:
+		version.Metadata = appendIdentifier(version.Metadata, dirtyIdentifier)
+	}
+}
+
+func appendIdentifier(identifiers string, identifier string) string {
+	if identifiers == "" {
+		return identifier
+	}
+	return identifiers + "." + identifier
+}
+
+func validateDirtyMarker(marker string) SettingsErrors {
+	switch marker {
+	case "", DirtyMarkerNone, DirtyMarkerPrerelease, DirtyMarkerMetadata:
+		return SettingsErrors{}
+	}
+
+	return SettingsErrors{&SettingsError{
+		Key:     "dirty-marker",
+		Message: fmt.Sprintf("invalid marker '%s', expected %s, %s or %s", marker, DirtyMarkerNone, DirtyMarkerPrerelease, DirtyMarkerMetadata),
+	}}
+}
@@ -0,0 +1,106 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+
+	igit "github.com/syncromatics/gogitver/pkg/git"
+)
+
+func commitFile(t *testing.T, worktree *git.Worktree, name string, content string) {
+	writeFile(t, worktree, name, content)
+
+	_, err := worktree.Add(name)
+	assert.Nil(t, err)
+
+	commitMultiple(t, worktree, "(+semver: minor) add "+name+"\n")
+}
+
+func writeFile(t *testing.T, worktree *git.Worktree, name string, content string) {
+	file, err := worktree.Filesystem.Create(name)
+	assert.Nil(t, err)
+
+	_, err = file.Write([]byte(content))
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+}
+
+func Test_ShouldNotMarkCleanWorktree(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+	commitFile(t, worktree, "README.md", "hello")
+	writeFile(t, worktree, "untracked.txt", "not counted")
+
+	settings := igit.GetDefaultSettings()
+	settings.DirtyMarker = igit.DirtyMarkerPrerelease
+
+	// Act
+	info, err := igit.GetCurrentVersionInfo(repository, settings, &igit.BranchSettings{IgnoreEnvVars: true, FailOnDirty: true}, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.False(t, info.Dirty)
+	assert.Equal(t, "0.1.0", info.Version.String())
+}
+
+func Test_ShouldMarkDirtyWorktree(t *testing.T) {
+	for _, test := range []struct {
+		marker   string
+		expected string
+	}{
+		{igit.DirtyMarkerNone, "0.1.0"},
+		{igit.DirtyMarkerPrerelease, "0.1.0-dirty"},
+		{igit.DirtyMarkerMetadata, "0.1.0+dirty"},
+	} {
+		t.Run(test.marker, func(t *testing.T) {
+			// Arrange
+			repository, worktree := initRepository(t)
+			commitFile(t, worktree, "README.md", "hello")
+			writeFile(t, worktree, "README.md", "changed")
+
+			settings := igit.GetDefaultSettings()
+			settings.DirtyMarker = test.marker
+
+			// Act
+			version, err := igit.GetCurrentVersion(repository, settings, &igit.BranchSettings{IgnoreEnvVars: true}, false)
+
+			// Assert
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, version)
+		})
+	}
+}
+
+func Test_ShouldAppendDirtyToBranchPrerelease(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+	commitFile(t, worktree, "README.md", "hello")
+	checkout(t, worktree, "refs/heads/feature", true)
+	head := commitMultiple(t, worktree, "(+semver: fix) a fix\n")
+	writeFile(t, worktree, "README.md", "changed")
+
+	settings := igit.GetDefaultSettings()
+	settings.DirtyMarker = igit.DirtyMarkerPrerelease
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, &igit.BranchSettings{IgnoreEnvVars: true}, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1.1-feature-0-"+head.String()[:4]+".dirty", version)
+}
+
+func Test_ShouldFailOnDirtyWorktree(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+	commitFile(t, worktree, "README.md", "hello")
+	writeFile(t, worktree, "README.md", "changed")
+
+	// Act
+	_, err := igit.GetCurrentVersion(repository, igit.GetDefaultSettings(), &igit.BranchSettings{IgnoreEnvVars: true, FailOnDirty: true}, false)
+
+	// Assert
+	assert.EqualError(t, err, "GetCurrentVersion failed: the working tree has uncommitted changes")
+}
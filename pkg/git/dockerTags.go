@@ -0,0 +1,30 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DockerTagSettings controls which tags the docker-tags output lists for a version
+type DockerTagSettings struct {
+	// Latest tags mainline versions as latest
+	Latest bool `yaml:"latest"`
+	// Floating tags mainline versions with their major and major.minor versions
+	Floating bool `yaml:"floating"`
+	// Branch tags prerelease versions with their branch name as well
+	Branch bool `yaml:"branch"`
+	// Prefix is prepended to every version tag, e.g. v
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+var dockerTagPrefix = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+func (d *DockerTagSettings) validate() SettingsErrors {
+	errs := SettingsErrors{}
+
+	if d.Prefix != "" && !dockerTagPrefix.MatchString(d.Prefix) {
+		errs = append(errs, &SettingsError{Key: "docker-tags.prefix", Message: fmt.Sprintf("'%s' is not a valid start of a docker tag", d.Prefix)})
+	}
+
+	return errs
+}
@@ -5,7 +5,9 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/pkg/errors"
@@ -19,6 +21,7 @@ type BranchSettings struct {
 	ForbidBehindMaster bool
 	TrimBranchPrefix   bool
 	IgnoreEnvVars      bool
+	FailOnDirty        bool
 }
 
 type gitVersion struct {
@@ -28,29 +31,247 @@ type gitVersion struct {
 	MajorBump bool
 	MinorBump bool
 	PatchBump bool
+	NoBump    bool
+	Ignored   bool
 	Commit    string
 }
 
+// VersionInfo is a calculated version along with the commit and branch it was calculated for
+type VersionInfo struct {
+	Version    *semver.Version
+	Commit     string
+	CommitDate time.Time
+	Branch     string
+	// PullRequest is the pull request the version was calculated for, nil for other builds
+	PullRequest *PullRequest
+	// MergedVersion is the version the target branch of the pull request would have after merging it
+	MergedVersion *semver.Version
+	// Dirty is set when the working tree has uncommitted changes. It is only checked when a dirty
+	// marker is configured or a dirty working tree is forbidden.
+	Dirty bool
+}
+
 // GetCurrentVersion returns the current version
 func GetCurrentVersion(r *git.Repository, settings *Settings, branchSettings *BranchSettings, verbose bool) (version string, err error) {
+	info, err := GetCurrentVersionInfo(r, settings, branchSettings, verbose)
+	if err != nil {
+		return "", err
+	}
+
+	return info.Version.String(), nil
+}
+
+// GetCurrentVersionInfo returns the current version along with the commit and branch it was calculated for
+func GetCurrentVersionInfo(r *git.Repository, settings *Settings, branchSettings *BranchSettings, verbose bool) (*VersionInfo, error) {
+	info, err := getCurrentVersionInfo(r, settings, branchSettings, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	err = info.checkDirty(r, settings, branchSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCurrentVersion failed")
+	}
+
+	return info, nil
+}
+
+func getCurrentVersionInfo(r *git.Repository, settings *Settings, branchSettings *BranchSettings, verbose bool) (*VersionInfo, error) {
 	tag, ok := os.LookupEnv("TRAVIS_TAG")
 	if !branchSettings.IgnoreEnvVars && ok && tag != "" { // If this is a tagged build in travis shortcircuit here
 		version, err := parseTag(tag)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if verbose {
 			log.Printf("Version determined using TRAVIS_TAG")
 		}
-		return version.String(), err
+
+		info := &VersionInfo{Version: version}
+		if h, err := r.Head(); err == nil {
+			err = info.setCommit(r, h.Hash())
+			if err != nil {
+				return nil, errors.Wrap(err, "GetCurrentVersion failed")
+			}
+		}
+		return info, nil
+	}
+
+	tagMap, err := getTagMap(r, verbose)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCurrentVersion failed")
+	}
+
+	h, err := r.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCurrentVersion failed")
+	}
+
+	pr, err := getPullRequest(r, h, branchSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCurrentVersion failed")
+	}
+
+	var currentBranch string
+	if pr != nil {
+		if verbose {
+			log.Printf("Building pull request %d into %s", pr.Number, pr.TargetBranch)
+		}
+		currentBranch, err = pr.branchName(branchSettings.TrimBranchPrefix)
+	} else {
+		currentBranch, err = getCurrentBranch(r, h, branchSettings)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCurrentVersion failed")
+	}
+
+	info, err := getVersion(r, h.Hash(), currentBranch, pr, tagMap, branchSettings, settings, verbose)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCurrentVersion failed")
+	}
+
+	err = info.setCommit(r, h.Hash())
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCurrentVersion failed")
+	}
+
+	return info, nil
+}
+
+// GetVersionAtRevision returns the version of any revision, such as a commit hash, branch or tag.
+// The prerelease label comes from the branch named by the revision or, failing that, a branch
+// that points at it. Continuous integration environment variables are not consulted.
+func GetVersionAtRevision(r *git.Repository, revision string, settings *Settings, branchSettings *BranchSettings, verbose bool) (version string, err error) {
+	info, err := GetVersionInfoAtRevision(r, revision, settings, branchSettings, verbose)
+	if err != nil {
+		return "", err
+	}
+
+	return info.Version.String(), nil
+}
+
+// GetVersionInfoAtRevision is GetVersionAtRevision returning the commit and branch along with the version
+func GetVersionInfoAtRevision(r *git.Repository, revision string, settings *Settings, branchSettings *BranchSettings, verbose bool) (*VersionInfo, error) {
+	tagMap, err := getTagMap(r, verbose)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionAtRevision failed")
+	}
+
+	revisionSettings := *branchSettings
+	revisionSettings.IgnoreEnvVars = true
+
+	hash, branch, err := resolveRevision(r, revision, &revisionSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionAtRevision failed")
+	}
+
+	info, err := getVersion(r, hash, branch, nil, tagMap, &revisionSettings, settings, verbose)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionAtRevision failed")
+	}
+
+	err = info.setCommit(r, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionAtRevision failed")
+	}
+
+	return info, nil
+}
+
+func (i *VersionInfo) setCommit(r *git.Repository, h plumbing.Hash) error {
+	commit, err := r.CommitObject(h)
+	if err != nil {
+		return err
+	}
+
+	i.Commit = commit.Hash.String()
+	i.CommitDate = commit.Committer.When
+	return nil
+}
+
+// VersionHistoryEntry is the version master had when a commit on its first-parent history was the head
+type VersionHistoryEntry struct {
+	Version string    `json:"version"`
+	Commit  string    `json:"commit"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+}
+
+// GetVersionHistory returns the version of every commit on the first-parent history of master,
+// newest first. The versions are calculated in a single walk of the history.
+func GetVersionHistory(r *git.Repository, settings *Settings, verbose bool) ([]*VersionHistoryEntry, error) {
+	settings, err := settings.compiled()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionHistory failed")
+	}
+
+	tagMap, err := getTagMap(r, verbose)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionHistory failed")
+	}
+
+	masterHead, err := getMasterHead(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionHistory failed")
+	}
+
+	masterCommit, err := r.CommitObject(masterHead.Hash())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get master commit from reference")
+	}
+
+	walker := newBranchWalker(r, masterCommit, tagMap, settings, true, "", nil, verbose)
+	walker.throughTags = true
+	versionMap, err := walker.GetVersionMap()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetVersionHistory failed")
+	}
+
+	history := []*VersionHistoryEntry{}
+	for index, version := range walker.getVersions(versionMap) {
+		commit, err := r.CommitObject(plumbing.NewHash(versionMap[index].Commit))
+		if err != nil {
+			return nil, errors.Wrap(err, "GetVersionHistory failed")
+		}
+
+		history = append(history, &VersionHistoryEntry{
+			Version: applyNextVersion(version, settings, false).String(),
+			Commit:  commit.Hash.String(),
+			Date:    commit.Committer.When,
+			Subject: strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+		})
+	}
+
+	return history, nil
+}
+
+// GetPrereleaseLabel returns the branch the prerelease label of the current build is made from,
+// the value of {branch} in the prerelease templates. For a pull request build it is the source
+// branch of the pull request.
+func GetPrereleaseLabel(r *git.Repository, settings *Settings, branchSettings *BranchSettings) (result string, err error) {
+	h, err := r.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "GetCurrentVersion failed")
 	}
 
+	pr, err := getPullRequest(r, h, branchSettings)
+	if err != nil {
+		return "", errors.Wrap(err, "GetPrereleaseLabel failed")
+	}
+	if pr != nil {
+		return pr.branchName(branchSettings.TrimBranchPrefix)
+	}
+
+	return getCurrentBranch(r, h, branchSettings)
+}
+
+func getTagMap(r *git.Repository, verbose bool) (map[string]string, error) {
 	tagMap := make(map[string]string)
 
 	// lightweight tags
 	ltags, err := r.Tags()
 	if err != nil {
-		return "", errors.Wrap(err, "get tags failed")
+		return nil, errors.Wrap(err, "get tags failed")
 	}
 
 	err = ltags.ForEach(func(ref *plumbing.Reference) error {
@@ -66,7 +287,7 @@ func GetCurrentVersion(r *git.Repository, settings *Settings, branchSettings *Br
 	// annotated tags
 	tags, err := r.TagObjects()
 	if err != nil {
-		return "", errors.Wrap(err, "get tag objects failed")
+		return nil, errors.Wrap(err, "get tag objects failed")
 	}
 
 	err = tags.ForEach(func(ref *object.Tag) error {
@@ -81,46 +302,163 @@ func GetCurrentVersion(r *git.Repository, settings *Settings, branchSettings *Br
 		return nil
 	})
 	if err != nil {
-		return "", errors.Wrap(err, "GetCurrentVersion failed")
+		return nil, err
 	}
 
-	h, err := r.Head()
+	return tagMap, nil
+}
+
+// resolveRevision returns the commit a revision refers to and the cleansed name of its branch. When
+// the revision is not a branch, the branch is one that points at the commit or, failing that, one
+// that contains it. It is empty for commits in the history of master and for commits that no
+// branch contains.
+func resolveRevision(r *git.Repository, revision string, branchSettings *BranchSettings) (plumbing.Hash, string, error) {
+	for _, name := range []string{revision, "refs/heads/" + revision, "refs/remotes/" + revision, "refs/remotes/origin/" + revision} {
+		ref, err := r.Reference(plumbing.ReferenceName(name), true)
+		if err != nil || !(ref.Name().IsBranch() || ref.Name().IsRemote()) {
+			continue
+		}
+
+		branchName := ref.Name().Short()
+		if ref.Name().IsRemote() {
+			branchName = strings.SplitN(branchName, "/", 2)[1]
+		}
+
+		branch, err := cleanseBranchName(branchName, branchSettings.TrimBranchPrefix)
+		if err != nil {
+			return plumbing.ZeroHash, "", err
+		}
+		return ref.Hash(), branch, nil
+	}
+
+	commit, err := resolveCommit(r, revision)
 	if err != nil {
-		return "", errors.Wrap(err, "GetCurrentVersion failed")
+		return plumbing.ZeroHash, "", err
 	}
 
-	v, err := getVersion(r, h, tagMap, branchSettings, settings, verbose)
+	branch, err := getCurrentBranch(r, plumbing.NewHashReference(plumbing.HEAD, commit.Hash), branchSettings)
 	if err != nil {
-		return "", errors.Wrap(err, "GetCurrentVersion failed")
+		branch, err = getContainingBranch(r, commit, branchSettings)
+		if err != nil {
+			return plumbing.ZeroHash, "", errors.Wrapf(err, "failed to find a branch containing revision '%s'", revision)
+		}
 	}
 
-	return v.String(), nil
+	return commit.Hash, branch, nil
 }
 
-// GetPrereleaseLabel returns the prerelease label for the current branch
-func GetPrereleaseLabel(r *git.Repository, settings *Settings, branchSettings *BranchSettings) (result string, err error) {
-	h, err := r.Head()
+// resolveCommit returns the commit a revision refers to, peeling annotated tags.
+func resolveCommit(r *git.Repository, revision string) (*object.Commit, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(revision))
 	if err != nil {
-		return "", errors.Wrap(err, "GetCurrentVersion failed")
+		return nil, errors.Wrapf(err, "failed to resolve revision '%s'", revision)
 	}
-	return getCurrentBranch(r, h, branchSettings)
+
+	commit, err := r.CommitObject(*hash)
+	if err != nil {
+		tag, tagErr := r.TagObject(*hash)
+		if tagErr != nil {
+			return nil, errors.Wrapf(err, "revision '%s' is not a commit", revision)
+		}
+
+		commit, err = tag.Commit()
+		if err != nil {
+			return nil, errors.Wrapf(err, "revision '%s' is not a commit", revision)
+		}
+	}
+
+	return commit, nil
+}
+
+// getContainingBranch returns the cleansed name of a branch whose history contains commit, preferring
+// local branches and otherwise the first by name. It is empty when the commit is in the history of
+// master, which versions it as master was, or when no branch contains it.
+func getContainingBranch(r *git.Repository, commit *object.Commit, branchSettings *BranchSettings) (string, error) {
+	masterHead, err := getMasterHead(r)
+	if err != nil {
+		return "", err
+	}
+
+	contains := func(hash plumbing.Hash) (bool, error) {
+		tip, err := r.CommitObject(hash)
+		if err != nil {
+			return false, nil // not every ref points at a commit
+		}
+		base, err := mergeBase(commit, tip)
+		if err != nil {
+			return false, err
+		}
+		return base != nil && base.Hash == commit.Hash, nil
+	}
+
+	onMaster, err := contains(masterHead.Hash())
+	if err != nil || onMaster {
+		return "", err
+	}
+
+	refs, err := r.References()
+	if err != nil {
+		return "", err
+	}
+
+	local, remote := []string{}, []string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || !(ref.Name().IsBranch() || ref.Name().IsRemote()) {
+			return nil
+		}
+
+		found, err := contains(ref.Hash())
+		if err != nil || !found {
+			return err
+		}
+
+		if ref.Name().IsBranch() {
+			local = append(local, ref.Name().Short())
+		} else {
+			remote = append(remote, strings.SplitN(ref.Name().Short(), "/", 2)[1])
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(local)
+	sort.Strings(remote)
+	names := append(local, remote...)
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	return cleanseBranchName(names[0], branchSettings.TrimBranchPrefix)
 }
 
-func getVersion(r *git.Repository, h *plumbing.Reference, tagMap map[string]string, branchSettings *BranchSettings, settings *Settings, verbose bool) (version *semver.Version, err error) {
-	currentBranch, err := getCurrentBranch(r, h, branchSettings)
+// getVersion calculates the version of the commit with hash h on the branch currentBranch. When
+// currentBranch is empty and the commit is in the history of master, it is versioned as master was
+// when the commit was its head; otherwise it is labelled with its abbreviated hash alone. For a
+// pull request build the commits of the pull request are walked against its target branch, the
+// version is labelled with the pull request template and the version the target branch would have
+// after merging the pull request is calculated as well. The commit details of the returned version
+// info are not set.
+func getVersion(r *git.Repository, h plumbing.Hash, currentBranch string, pr *PullRequest, tagMap map[string]string, branchSettings *BranchSettings, settings *Settings, verbose bool) (*VersionInfo, error) {
+	info := &VersionInfo{Branch: currentBranch, PullRequest: pr}
+	result := func(version *semver.Version) (*VersionInfo, error) {
+		info.Version = version
+		return info, nil
+	}
+
+	settings, err := settings.compiled()
 	if err != nil {
 		return nil, errors.Wrap(err, "getVersion failed")
 	}
-	if verbose {
+
+	if verbose && currentBranch != "" {
 		log.Printf("Current branch is %s", currentBranch)
 	}
 
-	masterHead, err := r.Reference("refs/heads/master", false)
+	masterHead, err := getMasterHead(r)
 	if err != nil {
-		masterHead, err = r.Reference("refs/remotes/origin/master", false) // TODO: This needs test coverage
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to get master branch at 'refs/heads/master, 'refs/remotes/origin/master'")
-		}
+		return nil, err
 	}
 
 	masterCommit, err := r.CommitObject(masterHead.Hash())
@@ -128,22 +466,74 @@ func getVersion(r *git.Repository, h *plumbing.Reference, tagMap map[string]stri
 		return nil, errors.Wrap(err, "failed to get master commit from reference")
 	}
 
-	masterWalker := newBranchWalker(r, masterCommit, tagMap, settings, true, "", verbose)
+	masterWalker := newBranchWalker(r, masterCommit, tagMap, settings, true, "", nil, verbose)
 	masterVersion, err := masterWalker.GetVersion()
 	if err != nil {
 		return nil, err
 	}
+	masterVersion = applyNextVersion(masterVersion, settings, verbose)
 
-	if h.Hash() == masterHead.Hash() {
-		return masterVersion, nil
+	if h == masterHead.Hash() {
+		return result(masterVersion)
 	}
 
-	c, err := r.CommitObject(h.Hash())
+	c, err := r.CommitObject(h)
 	if err != nil {
 		return nil, errors.Wrap(err, "getVersion failed")
 	}
 
-	walker := newBranchWalker(r, c, tagMap, settings, false, masterHead.Hash().String(), verbose)
+	head, target := c, masterCommit
+	if pr != nil {
+		target = getTargetCommit(r, pr, masterCommit)
+		head, err = pullRequestHead(c, target)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find the head of the pull request")
+		}
+		if verbose && head != c {
+			log.Printf("Commit %s merges the pull request head %s into %s", h, head.Hash, pr.TargetBranch)
+		}
+
+		info.MergedVersion, err = getMergedVersion(r, head, target, masterCommit, masterVersion, tagMap, settings, verbose)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	forkPoint, err := mergeBase(head, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find where the branch forked from master")
+	}
+
+	if currentBranch == "" && forkPoint != nil && forkPoint.Hash == h {
+		if verbose {
+			log.Printf("Commit %s is in the history of master", h)
+		}
+		mainlineWalker := newBranchWalker(r, c, tagMap, settings, true, "", nil, verbose)
+		mainlineVersion, err := mainlineWalker.GetVersion()
+		if err != nil {
+			return nil, err
+		}
+		return result(applyNextVersion(mainlineVersion, settings, verbose))
+	}
+
+	endHash := ""
+	if forkPoint != nil {
+		endHash = forkPoint.Hash.String()
+		if verbose {
+			log.Printf("Branch forked from master at %s", endHash)
+		}
+	}
+
+	branchCommits, err := commitsBetween(head, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the commits of the branch")
+	}
+
+	walker := newBranchWalker(r, head, tagMap, settings, false, endHash, masterWalker.visited, verbose)
+	walker.branch = map[string]bool{}
+	for _, commit := range branchCommits {
+		walker.branch[commit.Hash.String()] = true
+	}
 	versionMap, err := walker.GetVersionMap()
 	if err != nil {
 		return nil, err
@@ -156,14 +546,15 @@ func getVersion(r *git.Repository, h *plumbing.Reference, tagMap map[string]stri
 	}
 
 	if versionMap[index].IsSolid {
-		baseVersion = versionMap[index].Name
+		baseVersion = applyNextVersion(versionMap[index].Name, settings, verbose)
 		index--
 	} else {
-		baseVersion = masterVersion
+		base := *masterVersion
+		baseVersion = &base
 	}
 
 	if index < 0 {
-		return baseVersion, nil
+		return result(baseVersion)
 	}
 
 	for ; index >= 0; index-- {
@@ -178,15 +569,54 @@ func getVersion(r *git.Repository, h *plumbing.Reference, tagMap map[string]stri
 		}
 	}
 
-	shortHash := h.Hash().String()[:4]
-	prerelease := fmt.Sprintf("%s-%d-%s", currentBranch, len(versionMap)-1, shortHash)
+	shortHash := h.String()[:4]
+	prerelease := shortHash
+	if currentBranch != "" {
+		prerelease = settings.formatPrerelease(&prereleaseValues{
+			branch:      currentBranch,
+			commits:     len(versionMap) - 1,
+			sha:         shortHash,
+			pullRequest: pr,
+		})
+	}
 	baseVersion.PreRelease = semver.PreRelease(prerelease)
 
 	if branchSettings.ForbidBehindMaster && baseVersion.LessThan(*masterVersion) {
 		return nil, errors.Errorf("Branch has calculated version '%s' whose version is less than master '%s'", baseVersion, masterVersion)
 	}
 
-	return baseVersion, nil
+	return result(baseVersion)
+}
+
+func getMasterHead(r *git.Repository) (*plumbing.Reference, error) {
+	masterHead, err := r.Reference("refs/heads/master", false)
+	if err != nil {
+		masterHead, err = r.Reference("refs/remotes/origin/master", false) // TODO: This needs test coverage
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get master branch at 'refs/heads/master, 'refs/remotes/origin/master'")
+		}
+	}
+
+	return masterHead, nil
+}
+
+// applyNextVersion returns the version from the next-version setting in place of a calculated
+// version that is lower than it.
+func applyNextVersion(version *semver.Version, settings *Settings, verbose bool) *semver.Version {
+	if settings.NextVersion == "" {
+		return version
+	}
+
+	next, err := parseTag(settings.NextVersion)
+	if err != nil || !version.LessThan(*next) {
+		return version
+	}
+
+	if verbose {
+		log.Printf("next-version %s is in effect, the calculated version %s is lower", next, version)
+	}
+
+	return next
 }
 
 func getCurrentBranch(r *git.Repository, h *plumbing.Reference, branchSettings *BranchSettings) (name string, err error) {
@@ -246,22 +676,17 @@ func getCurrentBranch(r *git.Repository, h *plumbing.Reference, branchSettings *
 	return branch, nil
 }
 
-func cleanseBranchName(name string, trimPrefix bool) (string, error) {
-	reg, err := regexp.Compile("[^a-zA-Z0-9]+")
-	if err != nil {
-		return "", err
-	}
+var (
+	invalidBranchCharacters = regexp.MustCompile("[^a-zA-Z0-9]+")
+	trimmedBranchPrefixes   = regexp.MustCompile("^(feature|hotfix)-")
+)
 
-	branchName := reg.ReplaceAllString(name, "-")
+func cleanseBranchName(name string, trimPrefix bool) (string, error) {
+	branchName := invalidBranchCharacters.ReplaceAllString(name, "-")
 	if !trimPrefix {
 		return branchName, nil
 	}
 
-	reg, err = regexp.Compile("^(feature|hotfix)-")
-	if err != nil {
-		return "", err
-	}
-
-	branchName = reg.ReplaceAllString(branchName, "")
+	branchName = trimmedBranchPrefixes.ReplaceAllString(branchName, "")
 	return branchName, nil
 }
@@ -3,6 +3,7 @@ package git_test
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -283,6 +284,7 @@ func Test_ShouldCalculateVersionFromTravisTag(t *testing.T) {
 	settings := igit.GetDefaultSettings()
 	branchSettings := &igit.BranchSettings{}
 	os.Setenv("TRAVIS_TAG", "v1.2.3")
+	defer os.Unsetenv("TRAVIS_TAG")
 
 	// Act
 	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
@@ -404,3 +406,544 @@ func defaultSignature() *object.Signature {
 		When:  when,
 	}
 }
+
+func Test_ShouldNotBumpVersionForIgnoredCommits(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree,
+		"(+semver: minor) This is a minor commit\n",
+		"chore(release): 0.1.0\n",
+		"This is a patch commit\n",
+	)
+
+	skippedHash, err := worktree.Commit("(+semver: major) Reverted later\n", &git.CommitOptions{Author: defaultSignature()})
+	assert.Nil(t, err)
+
+	_, err = worktree.Commit("Bump dependency\n", &git.CommitOptions{Author: &object.Signature{
+		Name:  "bot",
+		Email: "Bot@Example.com",
+		When:  defaultSignature().When,
+	}})
+	assert.Nil(t, err)
+
+	commitMultiple(t, worktree, "This is another patch commit\n")
+
+	settings, err := igit.GetSettingsFromFile(strings.NewReader(fmt.Sprintf(`
+ignore:
+  shas: ['%s']
+  messages: ['^chore\(release\)']
+  authors: ['bot@example.com']
+`, skippedHash.String()[:7])))
+	assert.Nil(t, err)
+
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "0.1.2", version)
+}
+
+func Test_ShouldReconcileCommitsMergedByIgnoredMerge(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature", initial)
+	feature := commitMultiple(t, worktree, "(+semver: minor) A feature\n")
+
+	branchFrom(t, worktree, "docs", initial)
+	docs := commitMultiple(t, worktree, "(+semver: none) Update docs\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	master := commitMerge(t, worktree, "chore(release): merge feature\n", initial, feature)
+	master = commitMerge(t, worktree, "chore(release): merge docs\n", master, docs)
+	commitMerge(t, worktree, "chore(release): merge feature again\n", master, feature)
+
+	settings, err := igit.GetSettingsFromFile(strings.NewReader(`
+ignore:
+  messages: ['^chore\(release\)']
+`))
+	assert.Nil(t, err)
+
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "0.1.0", version)
+}
+
+func Test_ShouldNotBumpVersionForCommitsBeforeIgnoreDate(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree,
+		"(+semver: major) Before the cut off\n",
+		"(+semver: major) Also before the cut off\n",
+	)
+
+	_, err := worktree.Commit("(+semver: minor) After the cut off\n", &git.CommitOptions{Author: &object.Signature{
+		Name:  "foo",
+		Email: "foo@foo.foo",
+		When:  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+	}})
+	assert.Nil(t, err)
+
+	settings, err := igit.GetSettingsFromFile(strings.NewReader(`
+ignore:
+  before: 2018-01-01
+`))
+	assert.Nil(t, err)
+
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "0.1.0", version)
+}
+
+func Test_ShouldUseNextVersionWhenCalculatedVersionIsLower(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree,
+		"(+semver: minor) This is a minor commit\n",
+		"This is a patch commit\n",
+	)
+
+	settings := igit.GetDefaultSettings()
+	settings.NextVersion = "v5.0.0"
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "5.0.0", version)
+}
+
+func Test_ShouldIgnoreNextVersionWhenCalculatedVersionIsHigher(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree,
+		"(+semver: major) This is a major commit\n",
+		"(+semver: major) This is a major commit\n",
+		"This is a patch commit\n",
+	)
+
+	settings := igit.GetDefaultSettings()
+	settings.NextVersion = "1.5.0"
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "2.0.1", version)
+}
+
+func Test_ShouldUseNextVersionAsBaseInBranch(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree, "Initial commit")
+
+	err := worktree.Checkout(&git.CheckoutOptions{
+		Create: true,
+		Branch: plumbing.ReferenceName("refs/heads/a-branch"),
+	})
+	assert.Nil(t, err)
+
+	hash := commitMultiple(t, worktree,
+		"(+semver: minor)\n",
+		"some text\n",
+	)
+
+	settings := igit.GetDefaultSettings()
+	settings.NextVersion = "5.0.0"
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	expected := fmt.Sprintf("5.1.0-a-branch-1-%s", hash.String()[0:4])
+	assert.Equal(t, expected, version)
+}
+
+func Test_ShouldNotBumpVersionForNoBumpCommits(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree,
+		"(+semver: minor) This is a minor commit\n",
+		"(+semver: none) Fix typo in readme\n",
+		"This is a patch commit\n",
+		"(+semver: skip) Update docs\n",
+	)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "0.1.1", version)
+}
+
+func Test_ShouldNotBumpVersionForMergeOfNoBumpCommits(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	masterHash := commitMultiple(t, worktree, "Initial commit")
+
+	err := worktree.Checkout(&git.CheckoutOptions{
+		Create: true,
+		Branch: plumbing.ReferenceName("refs/heads/docs"),
+	})
+	assert.Nil(t, err)
+
+	branchHash := commitMultiple(t, worktree,
+		"(+semver: none) Update docs\n",
+		"(+semver: none) Update more docs\n",
+	)
+
+	err = worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.ReferenceName("refs/heads/master"),
+	})
+	assert.Nil(t, err)
+
+	_, err = worktree.Commit("merged docs\n", &git.CommitOptions{
+		Author: defaultSignature(),
+		Parents: []plumbing.Hash{
+			masterHash,
+			branchHash,
+		},
+	})
+	assert.Nil(t, err)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "0.0.1", version)
+}
+
+func Test_ShouldHonourMergeCommitMessages(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	masterHash := commitMultiple(t, worktree, "Initial commit")
+
+	checkout(t, worktree, "refs/heads/a-branch", true)
+	branchHash := commitMultiple(t, worktree, "(+semver: patch)\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	masterHash = commitMerge(t, worktree, "Merge PR #12: (+semver: feature) add a feature\n", masterHash, branchHash)
+
+	checkout(t, worktree, "refs/heads/another-branch", true)
+	branchHash = commitMultiple(t, worktree, "(+semver: major)\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	commitMerge(t, worktree, "Merge PR #13: (+semver: fix) a small fix\n", masterHash, branchHash)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "1.0.0", version)
+}
+
+func checkout(t *testing.T, worktree *git.Worktree, branch string, create bool) {
+	err := worktree.Checkout(&git.CheckoutOptions{
+		Create: create,
+		Branch: plumbing.ReferenceName(branch),
+	})
+	assert.Nil(t, err)
+}
+
+func commitMerge(t *testing.T, worktree *git.Worktree, message string, parents ...plumbing.Hash) plumbing.Hash {
+	hash, err := worktree.Commit(message, &git.CommitOptions{
+		Author:  defaultSignature(),
+		Parents: parents,
+	})
+	assert.Nil(t, err)
+
+	return hash
+}
+
+func Test_ShouldOnlyCountBranchCommitsWhenMasterAdvancedAfterBranching(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	forkPoint := commitMultiple(t, worktree,
+		"Initial commit",
+		"(+semver: minor) This is a minor commit\n",
+	)
+
+	branchFrom(t, worktree, "a-branch", forkPoint)
+	hash := commitMultiple(t, worktree, "(+semver: patch)\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	commitMultiple(t, worktree, "This is a patch commit\n")
+
+	checkout(t, worktree, "refs/heads/a-branch", false)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	expected := fmt.Sprintf("0.1.2-a-branch-0-%s", hash.String()[0:4])
+	assert.Equal(t, expected, version)
+}
+
+func Test_ShouldStopAtForkPointOfBranchWithMasterMergedIn(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	forkPoint := commitMultiple(t, worktree,
+		"Initial commit",
+		"(+semver: major) This is a major commit\n",
+	)
+
+	branchFrom(t, worktree, "a-branch", forkPoint)
+	branchHash := commitMultiple(t, worktree, "(+semver: minor)\n", "some text\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	masterHash := commitMultiple(t, worktree, "(+semver: minor) Another minor commit\n")
+
+	checkout(t, worktree, "refs/heads/a-branch", false)
+	branchHash = commitMerge(t, worktree, "Merge master into a-branch\n", branchHash, masterHash)
+
+	checkout(t, worktree, "refs/heads/master", false)
+	commitMultiple(t, worktree, "This is a patch commit\n")
+
+	checkout(t, worktree, "refs/heads/a-branch", false)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	expected := fmt.Sprintf("1.2.0-a-branch-2-%s", branchHash.String()[0:4])
+	assert.Equal(t, expected, version)
+}
+
+func Test_ShouldCalculateVersionAtRevision(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	first := commitMultiple(t, worktree,
+		"Initial commit",
+		"(+semver: minor) This is a minor commit\n",
+	)
+
+	ref := plumbing.NewHashReference(plumbing.ReferenceName("refs/tags/v0.1.0"), first)
+	err := repository.Storer.SetReference(ref)
+	assert.Nil(t, err)
+
+	second := commitMultiple(t, worktree,
+		"This is a patch commit\n",
+		"(+semver: major) This is a major commit\n",
+	)
+
+	branchFrom(t, worktree, "feature/a-branch", second)
+	branchHash := commitMultiple(t, worktree, "(+semver: minor)\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	commitMultiple(t, worktree, "This is a patch commit\n")
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		TrimBranchPrefix: true,
+	}
+
+	revisions := map[string]string{
+		"master":              "1.0.1",
+		"v0.1.0":              "0.1.0",
+		first.String() + "~1": "0.0.1",
+		second.String():       "1.0.0",
+		"HEAD~1":              "1.0.0",
+		"feature/a-branch":    "1.1.0-a-branch-0-" + branchHash.String()[:4],
+	}
+
+	for revision, expected := range revisions {
+		// Act
+		version, err := igit.GetVersionAtRevision(repository, revision, settings, branchSettings, false)
+		assert.Nil(t, err, revision)
+
+		// Assert
+		assert.Equal(t, expected, version, revision)
+	}
+}
+
+func Test_ShouldCalculateVersionAtRevisionBehindBranchTip(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature/a-branch", initial)
+	first := commitMultiple(t, worktree, "(+semver: minor)\n")
+	commitMultiple(t, worktree, "(+semver: major)\n")
+
+	branchFrom(t, worktree, "unreferenced", initial)
+	unreferenced := commitMultiple(t, worktree, "(+semver: minor) elsewhere\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	err := repository.Storer.RemoveReference("refs/heads/unreferenced")
+	assert.Nil(t, err)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		TrimBranchPrefix: true,
+	}
+
+	revisions := map[string]string{
+		"feature/a-branch~1":  "0.1.0-a-branch-0-" + first.String()[:4],
+		first.String():        "0.1.0-a-branch-0-" + first.String()[:4],
+		unreferenced.String(): "0.1.0-" + unreferenced.String()[:4],
+	}
+
+	for revision, expected := range revisions {
+		// Act
+		version, err := igit.GetVersionAtRevision(repository, revision, settings, branchSettings, false)
+		assert.Nil(t, err, revision)
+
+		// Assert
+		assert.Equal(t, expected, version, revision)
+	}
+}
+
+func Test_ShouldCalculateVersionHistoryOfMaster(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree,
+		"Initial commit",
+		"(+semver: minor) This is a minor commit\n",
+	)
+
+	ref := plumbing.NewHashReference(plumbing.ReferenceName("refs/tags/v2.0.0"), initial)
+	err := repository.Storer.SetReference(ref)
+	assert.Nil(t, err)
+
+	branchFrom(t, worktree, "a-branch", initial)
+	branchHash := commitMultiple(t, worktree, "(+semver: major)\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	merge := commitMerge(t, worktree, "Merge a-branch\n\nwith a body", initial, branchHash)
+	head := commitMultiple(t, worktree, "(+semver: none) Update docs\n")
+
+	settings := igit.GetDefaultSettings()
+
+	// Act
+	history, err := igit.GetVersionHistory(repository, settings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	versions := []string{}
+	for _, entry := range history {
+		versions = append(versions, entry.Version)
+	}
+	assert.Equal(t, []string{"3.0.0", "3.0.0", "2.0.0", "0.0.1"}, versions)
+
+	assert.Equal(t, head.String(), history[0].Commit)
+	assert.Equal(t, merge.String(), history[1].Commit)
+	assert.Equal(t, "Merge a-branch", history[1].Subject)
+	assert.Equal(t, defaultSignature().When.Unix(), history[1].Date.Unix())
+
+	for _, entry := range history {
+		version, err := igit.GetVersionAtRevision(repository, entry.Commit, settings, &igit.BranchSettings{}, false)
+		assert.Nil(t, err)
+		assert.Equal(t, version, entry.Version)
+	}
+}
+
+func Test_ShouldVersionBranchWhoseForkPointIsMissingFromShallowClone(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+	fork := commitMultiple(t, worktree, "fork point\n")
+
+	branchFrom(t, worktree, "feature", fork)
+	head := commitMultiple(t, worktree, "(+semver: minor) a feature\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	commitMultiple(t, worktree, "on master\n")
+
+	// a shallow clone has neither the fork point nor anything behind it
+	storage := repository.Storer.(*memory.Storage)
+	for _, hash := range []plumbing.Hash{initial, fork} {
+		delete(storage.Objects, hash)
+		delete(storage.Commits, hash)
+	}
+
+	checkout(t, worktree, "refs/heads/feature", false)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1.0-feature-0-"+head.String()[:4], version)
+}
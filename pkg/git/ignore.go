@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// IgnoreSettings selects commits that are walked through without contributing a version bump
+type IgnoreSettings struct {
+	SHAs     []string `yaml:"shas,omitempty"`
+	Messages []string `yaml:"messages,omitempty"`
+	Authors  []string `yaml:"authors,omitempty"`
+	Before   string   `yaml:"before,omitempty"`
+
+	messageRegexps []*regexp.Regexp
+	before         time.Time
+}
+
+var ignoreDateFormats = []string{time.RFC3339, "2006-01-02"}
+
+func (i *IgnoreSettings) validate() SettingsErrors {
+	errs := SettingsErrors{}
+
+	for index, sha := range i.SHAs {
+		if sha == "" {
+			errs = append(errs, &SettingsError{Key: "ignore.shas", Message: fmt.Sprintf("entry %d must not be empty", index)})
+		}
+	}
+
+	i.messageRegexps = []*regexp.Regexp{}
+	for index, message := range i.Messages {
+		if message == "" {
+			errs = append(errs, &SettingsError{Key: "ignore.messages", Message: fmt.Sprintf("entry %d must not be empty", index)})
+			continue
+		}
+
+		compiled, err := regexp.Compile(message)
+		if err != nil {
+			errs = append(errs, &SettingsError{Key: "ignore.messages", Message: fmt.Sprintf("invalid pattern in entry %d: %s", index, err)})
+			continue
+		}
+		i.messageRegexps = append(i.messageRegexps, compiled)
+	}
+
+	i.before = time.Time{}
+	if i.Before != "" {
+		var err error
+		for _, format := range ignoreDateFormats {
+			i.before, err = time.Parse(format, i.Before)
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			errs = append(errs, &SettingsError{Key: "ignore.before", Message: fmt.Sprintf("invalid date '%s', expected YYYY-MM-DD or RFC 3339", i.Before)})
+		}
+	}
+
+	return errs
+}
+
+// ignores reports whether a commit matches any of the ignore rules.
+func (i *IgnoreSettings) ignores(commit *object.Commit) bool {
+	hash := commit.Hash.String()
+	for _, sha := range i.SHAs {
+		if strings.HasPrefix(hash, strings.ToLower(sha)) {
+			return true
+		}
+	}
+
+	for _, message := range i.messageRegexps {
+		if message.MatchString(commit.Message) {
+			return true
+		}
+	}
+
+	for _, author := range i.Authors {
+		if strings.EqualFold(author, commit.Author.Email) {
+			return true
+		}
+	}
+
+	return !i.before.IsZero() && commit.Committer.When.Before(i.before)
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	billy "gopkg.in/src-d/go-billy.v4"
+)
+
+// commonPaths are the top level entries of a git directory that linked worktrees share with the
+// main worktree, as listed by git in path.c. Everything else, such as HEAD and the index, belongs
+// to each worktree.
+var commonPaths = map[string]bool{
+	"branches":    true,
+	"common":      true,
+	"config":      true,
+	"hooks":       true,
+	"info":        true,
+	"logs":        true,
+	"lost-found":  true,
+	"objects":     true,
+	"packed-refs": true,
+	"refs":        true,
+	"remotes":     true,
+	"rr-cache":    true,
+	"shallow":     true,
+	"svn":         true,
+	"worktrees":   true,
+}
+
+// worktreeRefs are the refs that are kept apart for each worktree even though refs are shared.
+var worktreeRefs = map[string]bool{
+	"bisect":    true,
+	"rewritten": true,
+	"worktree":  true,
+}
+
+// linkedWorktreeFilesystem presents the git directory of a linked worktree together with the
+// common directory it shares, so go-git, which does not read commondir files, finds the objects
+// and refs of the repository alongside the HEAD of the worktree.
+type linkedWorktreeFilesystem struct {
+	billy.Filesystem
+	worktree billy.Filesystem
+}
+
+func newLinkedWorktreeFilesystem(worktree billy.Filesystem, common billy.Filesystem) billy.Filesystem {
+	return &linkedWorktreeFilesystem{Filesystem: common, worktree: worktree}
+}
+
+// route returns the filesystem that holds the file at name.
+func (fs *linkedWorktreeFilesystem) route(name string) billy.Filesystem {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(name)), "/")
+	switch {
+	case parts[0] == "logs" && len(parts) > 1 && parts[1] == "HEAD":
+		return fs.worktree
+	case parts[0] == "logs" && len(parts) > 2 && parts[1] == "refs" && worktreeRefs[parts[2]]:
+		return fs.worktree
+	case parts[0] == "refs" && len(parts) > 1 && worktreeRefs[parts[1]]:
+		return fs.worktree
+	case commonPaths[parts[0]]:
+		return fs.Filesystem
+	default:
+		return fs.worktree
+	}
+}
+
+func (fs *linkedWorktreeFilesystem) Create(filename string) (billy.File, error) {
+	return fs.route(filename).Create(filename)
+}
+
+func (fs *linkedWorktreeFilesystem) Open(filename string) (billy.File, error) {
+	return fs.route(filename).Open(filename)
+}
+
+func (fs *linkedWorktreeFilesystem) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	return fs.route(filename).OpenFile(filename, flag, perm)
+}
+
+func (fs *linkedWorktreeFilesystem) Stat(filename string) (os.FileInfo, error) {
+	return fs.route(filename).Stat(filename)
+}
+
+func (fs *linkedWorktreeFilesystem) Lstat(filename string) (os.FileInfo, error) {
+	return fs.route(filename).Lstat(filename)
+}
+
+// Rename moves a file within the filesystem of newpath. go-git only renames temporary files into
+// place, which it creates next to their destination.
+func (fs *linkedWorktreeFilesystem) Rename(oldpath, newpath string) error {
+	return fs.route(newpath).Rename(oldpath, newpath)
+}
+
+func (fs *linkedWorktreeFilesystem) Remove(filename string) error {
+	return fs.route(filename).Remove(filename)
+}
+
+// TempFile creates temporary files at the top of the git directory in the common directory, where
+// go-git writes packed-refs before renaming it into place.
+func (fs *linkedWorktreeFilesystem) TempFile(dir, prefix string) (billy.File, error) {
+	if dir == "" {
+		return fs.Filesystem.TempFile(dir, prefix)
+	}
+	return fs.route(dir).TempFile(dir, prefix)
+}
+
+func (fs *linkedWorktreeFilesystem) ReadDir(path string) ([]os.FileInfo, error) {
+	return fs.route(path).ReadDir(path)
+}
+
+func (fs *linkedWorktreeFilesystem) MkdirAll(filename string, perm os.FileMode) error {
+	return fs.route(filename).MkdirAll(filename, perm)
+}
+
+func (fs *linkedWorktreeFilesystem) Symlink(target, link string) error {
+	return fs.route(link).Symlink(target, link)
+}
+
+func (fs *linkedWorktreeFilesystem) Readlink(link string) (string, error) {
+	return fs.route(link).Readlink(link)
+}
+
+func (fs *linkedWorktreeFilesystem) Chroot(path string) (billy.Filesystem, error) {
+	return fs.route(path).Chroot(path)
+}
@@ -0,0 +1,153 @@
+package git
+
+import (
+	"container/heap"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+const (
+	fromFirst  = 1 << iota // reachable from the first commit
+	fromSecond             // reachable from the second commit
+	stale                  // an ancestor of a common ancestor that was already found
+)
+
+// mergeBase returns the best common ancestor of a and b, or nil if they share no history. When
+// there is more than one best common ancestor, as after criss-cross merges, the most recently
+// committed one is returned.
+//
+// Commits are visited newest first, painting each with the sides it is reachable from, the same
+// way git merge-base does, so only the history back to the fork point is read.
+func mergeBase(a *object.Commit, b *object.Commit) (*object.Commit, error) {
+	if a.Hash == b.Hash {
+		return a, nil
+	}
+
+	flags := map[string]int{a.Hash.String(): fromFirst, b.Hash.String(): fromSecond}
+	queue := &commitQueue{}
+	heap.Push(queue, a)
+	heap.Push(queue, b)
+
+	candidates := []*object.Commit{}
+	for queue.hasUnpainted(flags) {
+		commit := heap.Pop(queue).(*object.Commit)
+		paint := flags[commit.Hash.String()]
+
+		if paint&(fromFirst|fromSecond) == fromFirst|fromSecond && paint&stale == 0 {
+			candidates = append(candidates, commit)
+			paint |= stale
+			flags[commit.Hash.String()] = paint
+		}
+
+		for i := 0; i < commit.NumParents(); i++ {
+			parent, err := commit.Parent(i)
+			if err != nil {
+				continue // the parent is missing from a shallow clone
+			}
+
+			hash := parent.Hash.String()
+			if flags[hash]&paint == paint {
+				continue
+			}
+			flags[hash] |= paint
+			heap.Push(queue, parent)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	return candidates[0], nil
+}
+
+// commitQueue is a priority queue of commits ordered from newest to oldest committer time.
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*object.Commit)) }
+
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// hasUnpainted reports whether any queued commit could still lead to a new common ancestor.
+func (q commitQueue) hasUnpainted(flags map[string]int) bool {
+	for _, commit := range q {
+		if flags[commit.Hash.String()]&stale == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// commitsBetween returns the commits reachable from to but not from any of from, newest first. It
+// paints commits the same way as mergeBase and stops once every queued commit is reachable from from.
+func commitsBetween(to *object.Commit, from ...*object.Commit) ([]*object.Commit, error) {
+	flags := map[string]int{to.Hash.String(): fromFirst}
+	queue := &commitQueue{}
+	heap.Push(queue, to)
+	for _, commit := range from {
+		if flags[commit.Hash.String()]&fromSecond != 0 {
+			continue
+		}
+		flags[commit.Hash.String()] |= fromSecond
+		heap.Push(queue, commit)
+	}
+
+	popped := []*object.Commit{}
+	seen := map[string]bool{}
+	for queue.hasUnreached(flags) {
+		commit := heap.Pop(queue).(*object.Commit)
+		hash := commit.Hash.String()
+		paint := flags[hash]
+
+		if !seen[hash] {
+			seen[hash] = true
+			popped = append(popped, commit)
+		}
+
+		for i := 0; i < commit.NumParents(); i++ {
+			parent, err := commit.Parent(i)
+			if err != nil {
+				continue // the parent is missing from a shallow clone
+			}
+
+			parentHash := parent.Hash.String()
+			if flags[parentHash]&paint == paint {
+				continue
+			}
+			flags[parentHash] |= paint
+			heap.Push(queue, parent)
+		}
+	}
+
+	commits := []*object.Commit{}
+	for _, commit := range popped {
+		if flags[commit.Hash.String()]&fromSecond == 0 {
+			commits = append(commits, commit)
+		}
+	}
+
+	return commits, nil
+}
+
+// hasUnreached reports whether any queued commit is not yet known to be reachable from the second commit.
+func (q commitQueue) hasUnreached(flags map[string]int) bool {
+	for _, commit := range q {
+		if flags[commit.Hash.String()]&fromSecond == 0 {
+			return true
+		}
+	}
+	return false
+}
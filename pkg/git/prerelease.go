@@ -0,0 +1,84 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The default prerelease templates. A branch build is labelled with its branch, the number of
+// commits on the branch and the abbreviated commit hash; a pull request build with the number of
+// the pull request and the number of commits.
+const (
+	DefaultPrereleaseTemplate            = "{branch}-{commits}-{sha}"
+	DefaultPullRequestPrereleaseTemplate = "pr.{number}.{commits}"
+)
+
+var (
+	templatePlaceholder  = regexp.MustCompile(`\{[^{}]*\}`)
+	templatePlaceholders = []string{"{branch}", "{commits}", "{sha}", "{number}", "{source}", "{target}"}
+)
+
+// prereleaseValues are substituted for the placeholders of a prerelease template
+type prereleaseValues struct {
+	branch      string
+	commits     int
+	sha         string
+	pullRequest *PullRequest
+}
+
+// formatPrerelease returns the prerelease label of a branch build, or of a pull request build
+// when values has a pull request. Empty templates are replaced by the defaults.
+func (s *Settings) formatPrerelease(values *prereleaseValues) string {
+	template := orDefault(s.PrereleaseTemplate, DefaultPrereleaseTemplate)
+	replacements := []string{
+		"{branch}", values.branch,
+		"{commits}", strconv.Itoa(values.commits),
+		"{sha}", values.sha,
+	}
+
+	if values.pullRequest != nil {
+		template = orDefault(s.PullRequestPrereleaseTemplate, DefaultPullRequestPrereleaseTemplate)
+		replacements = append(replacements,
+			"{number}", strconv.Itoa(values.pullRequest.Number),
+			"{source}", cleanseBranchNameOrEmpty(values.pullRequest.SourceBranch),
+			"{target}", cleanseBranchNameOrEmpty(values.pullRequest.TargetBranch))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+func cleanseBranchNameOrEmpty(name string) string {
+	branch, err := cleanseBranchName(name, false)
+	if err != nil {
+		return ""
+	}
+	return branch
+}
+
+func orDefault(template string, defaultTemplate string) string {
+	if template == "" {
+		return defaultTemplate
+	}
+	return template
+}
+
+func validatePrereleaseTemplate(key string, template string, allowed []string) SettingsErrors {
+	errs := SettingsErrors{}
+	for _, placeholder := range templatePlaceholder.FindAllString(template, -1) {
+		if !contains(allowed, placeholder) {
+			errs = append(errs, &SettingsError{Key: key, Message: fmt.Sprintf("unknown placeholder %s, expected one of %s", placeholder, strings.Join(allowed, ", "))})
+		}
+	}
+	return errs
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
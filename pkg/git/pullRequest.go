@@ -0,0 +1,207 @@
+package git
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// PullRequest is the pull or merge request a build is for
+type PullRequest struct {
+	Number int
+	// SourceBranch is the branch the pull request merges, empty when it is not known
+	SourceBranch string
+	// TargetBranch is the branch the pull request merges into, master when it is not known
+	TargetBranch string
+}
+
+// pullRequestVariables are the environment variables continuous integration providers set on
+// pull request builds. Providers that do not build pull requests set the number to something
+// other than a number, such as Travis' false.
+var pullRequestVariables = []struct {
+	number string
+	source string
+	target string
+}{
+	{"SYSTEM_PULLREQUEST_PULLREQUESTNUMBER", "SYSTEM_PULLREQUEST_SOURCEBRANCH", "SYSTEM_PULLREQUEST_TARGETBRANCH"}, // Azure Pipelines
+	{"CI_MERGE_REQUEST_IID", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_MERGE_REQUEST_TARGET_BRANCH_NAME"},         // GitLab
+	{"TRAVIS_PULL_REQUEST", "TRAVIS_PULL_REQUEST_BRANCH", "TRAVIS_BRANCH"},                                         // Travis
+	{"CHANGE_ID", "CHANGE_BRANCH", "CHANGE_TARGET"},                                                                // Jenkins
+	{"BITBUCKET_PR_ID", "BITBUCKET_BRANCH", "BITBUCKET_PR_DESTINATION_BRANCH"},                                     // Bitbucket Pipelines
+}
+
+// pullRequestRef matches the refs hosting providers publish for pull requests, either fetched
+// directly or into a remote, e.g. refs/pull/123/merge, refs/merge-requests/123/head or
+// refs/remotes/origin/pr/123.
+var pullRequestRef = regexp.MustCompile(`^refs/(?:remotes/[^/]+/)?(?:(?:pull|merge-requests)/(\d+)/(?:head|merge)|pr/(\d+))$`)
+
+// getPullRequest returns the pull request that is being built, or nil when the build is not for
+// one. The pull request is found in the environment of the continuous integration provider or,
+// failing that, from a pull request ref that points at a detached head.
+func getPullRequest(r *git.Repository, h *plumbing.Reference, branchSettings *BranchSettings) (*PullRequest, error) {
+	if !branchSettings.IgnoreEnvVars {
+		if pr := getPullRequestFromEnvironment(); pr != nil {
+			return pr, nil
+		}
+	}
+
+	if h.Name() != plumbing.HEAD {
+		return nil, nil
+	}
+
+	refs, err := r.References()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list references")
+	}
+
+	var pr *PullRequest
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if pr != nil || ref.Type() != plumbing.HashReference || ref.Hash() != h.Hash() {
+			return nil
+		}
+
+		match := pullRequestRef.FindStringSubmatch(ref.Name().String())
+		if match == nil {
+			return nil
+		}
+
+		number, err := strconv.Atoi(match[1] + match[2])
+		if err == nil {
+			pr = &PullRequest{Number: number, TargetBranch: "master"}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func getPullRequestFromEnvironment() *PullRequest {
+	if match := pullRequestRef.FindStringSubmatch(os.Getenv("GITHUB_REF")); match != nil { // GitHub Actions
+		number, err := strconv.Atoi(match[1] + match[2])
+		if err == nil {
+			return newPullRequest(number, os.Getenv("GITHUB_HEAD_REF"), os.Getenv("GITHUB_BASE_REF"))
+		}
+	}
+
+	for _, variables := range pullRequestVariables {
+		number, err := strconv.Atoi(os.Getenv(variables.number))
+		if err != nil || number <= 0 {
+			continue
+		}
+		return newPullRequest(number, os.Getenv(variables.source), os.Getenv(variables.target))
+	}
+
+	return nil
+}
+
+func newPullRequest(number int, source string, target string) *PullRequest {
+	target = strings.TrimPrefix(target, "refs/heads/")
+	if target == "" {
+		target = "master"
+	}
+
+	return &PullRequest{
+		Number:       number,
+		SourceBranch: strings.TrimPrefix(source, "refs/heads/"),
+		TargetBranch: target,
+	}
+}
+
+// branchName returns the name the pull request build is labelled with when the prerelease template
+// refers to the branch
+func (pr *PullRequest) branchName(trimPrefix bool) (string, error) {
+	if pr.SourceBranch == "" {
+		return "pr-" + strconv.Itoa(pr.Number), nil
+	}
+	return cleanseBranchName(pr.SourceBranch, trimPrefix)
+}
+
+// getTargetCommit returns the head of the branch a pull request merges into, falling back to master
+// when the branch cannot be found
+func getTargetCommit(r *git.Repository, pr *PullRequest, masterCommit *object.Commit) *object.Commit {
+	for _, name := range []string{"refs/heads/" + pr.TargetBranch, "refs/remotes/origin/" + pr.TargetBranch} {
+		ref, err := r.Reference(plumbing.ReferenceName(name), true)
+		if err != nil {
+			continue
+		}
+
+		commit, err := r.CommitObject(ref.Hash())
+		if err == nil {
+			return commit
+		}
+	}
+
+	return masterCommit
+}
+
+// pullRequestHead returns the head of the pull request when c is the merge commit a hosting
+// provider creates to build a pull request, whose first parent is in the history of the target
+// branch and whose second parent is the head of the pull request. Otherwise c itself is returned.
+func pullRequestHead(c *object.Commit, target *object.Commit) (*object.Commit, error) {
+	if c.NumParents() != 2 {
+		return c, nil
+	}
+
+	first, err := c.Parent(0)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := mergeBase(first, target)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil || base.Hash != first.Hash {
+		return c, nil
+	}
+
+	return c.Parent(1)
+}
+
+// getMergedVersion returns the version the target branch of a pull request would have after merging
+// the pull request head into it. Like a merge into master, the merge makes the highest bump asked
+// for by the commits of the pull request, or a patch bump when none asks for one.
+func getMergedVersion(r *git.Repository, head *object.Commit, target *object.Commit, masterCommit *object.Commit, masterVersion *semver.Version, tagMap map[string]string, settings *Settings, verbose bool) (*semver.Version, error) {
+	targetVersion := masterVersion
+	if target.Hash != masterCommit.Hash {
+		walker := newBranchWalker(r, target, tagMap, settings, true, "", nil, false)
+		version, err := walker.GetVersion()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate the version of the target branch")
+		}
+		targetVersion = applyNextVersion(version, settings, false)
+	}
+
+	_, _, bump, err := walkRange(r, head, target, settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to walk the commits of the pull request")
+	}
+
+	merged := *targetVersion
+	merged.PreRelease = ""
+	merged.Metadata = ""
+	switch bump {
+	case BumpMajor:
+		merged.BumpMajor()
+	case BumpMinor:
+		merged.BumpMinor()
+	case BumpPatch:
+		merged.BumpPatch()
+	}
+
+	if verbose {
+		log.Printf("Merging the pull request would make the version %s", merged.String())
+	}
+	return &merged, nil
+}
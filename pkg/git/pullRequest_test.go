@@ -0,0 +1,211 @@
+package git_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	igit "github.com/syncromatics/gogitver/pkg/git"
+)
+
+// pullRequestMerge creates the repository of a pull request build: a feature branch with two
+// commits that is merged into master, which has moved on since the branch was created, by a
+// merge commit that is checked out as a detached head.
+func pullRequestMerge(t *testing.T) (*git.Repository, plumbing.Hash) {
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature", initial)
+	feature := commitMultiple(t, worktree, "(+semver: minor) a feature\n", "some text\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	master := commitMultiple(t, worktree, "some more text\n")
+
+	err := worktree.Checkout(&git.CheckoutOptions{Hash: master})
+	assert.Nil(t, err)
+	merge := commitMerge(t, worktree, "Merge feature into master\n", master, feature)
+
+	return repository, merge
+}
+
+func Test_ShouldVersionPullRequestMergeFromRef(t *testing.T) {
+	// Arrange
+	repository, merge := pullRequestMerge(t)
+	err := repository.Storer.SetReference(plumbing.NewHashReference("refs/pull/7/merge", merge))
+	assert.Nil(t, err)
+
+	// Act
+	info, err := igit.GetCurrentVersionInfo(repository, igit.GetDefaultSettings(), &igit.BranchSettings{IgnoreEnvVars: true}, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1.0-pr.7.1", info.Version.String())
+	assert.Equal(t, "0.1.0", info.MergedVersion.String())
+	assert.Equal(t, &igit.PullRequest{Number: 7, TargetBranch: "master"}, info.PullRequest)
+	assert.Equal(t, "pr-7", info.Branch)
+}
+
+func Test_ShouldVersionPullRequestFromEnvironment(t *testing.T) {
+	// Arrange
+	repository, _ := pullRequestMerge(t)
+
+	environment := map[string]string{
+		"CI_MERGE_REQUEST_IID":                "12",
+		"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature/login",
+		"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "master",
+	}
+	for key, value := range environment {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	settings := igit.GetDefaultSettings()
+	settings.PullRequestPrereleaseTemplate = "{source}.pr{number}.{commits}"
+
+	// Act
+	info, err := igit.GetCurrentVersionInfo(repository, settings, &igit.BranchSettings{TrimBranchPrefix: true}, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1.0-feature-login.pr12.1", info.Version.String())
+	assert.Equal(t, "login", info.Branch)
+}
+
+func Test_ShouldNotTreatBranchAsPullRequest(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+	branchFrom(t, worktree, "feature", initial)
+	head := commitMultiple(t, worktree, "(+semver: minor) a feature\n")
+
+	err := repository.Storer.SetReference(plumbing.NewHashReference("refs/remotes/origin/pr/3", head))
+	assert.Nil(t, err)
+
+	settings := igit.GetDefaultSettings()
+	settings.PrereleaseTemplate = "{branch}.{sha}"
+
+	// Act
+	info, err := igit.GetCurrentVersionInfo(repository, settings, &igit.BranchSettings{IgnoreEnvVars: true}, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, info.PullRequest)
+	assert.Equal(t, "0.1.0-feature."+head.String()[:4], info.Version.String())
+}
+
+func Test_ShouldCalculateMergedVersionOfPullRequestWithoutKeywords(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature", initial)
+	feature := commitMultiple(t, worktree, "some text\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	master := commitMultiple(t, worktree, "(+semver: minor) on master\n")
+
+	err := worktree.Checkout(&git.CheckoutOptions{Hash: master})
+	assert.Nil(t, err)
+	merge := commitMerge(t, worktree, "Merge feature into master\n", master, feature)
+
+	err = repository.Storer.SetReference(plumbing.NewHashReference("refs/pull/9/merge", merge))
+	assert.Nil(t, err)
+
+	// Act
+	info, err := igit.GetCurrentVersionInfo(repository, igit.GetDefaultSettings(), &igit.BranchSettings{IgnoreEnvVars: true}, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1.0-pr.9.0", info.Version.String())
+	assert.Equal(t, "0.1.1", info.MergedVersion.String())
+}
+
+func Test_ShouldNotCalculateMergedVersionForBranch(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+	branchFrom(t, worktree, "feature", initial)
+	commitMultiple(t, worktree, "(+semver: minor) a feature\n")
+
+	// Act
+	info, err := igit.GetCurrentVersionInfo(repository, igit.GetDefaultSettings(), &igit.BranchSettings{IgnoreEnvVars: true}, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, info.MergedVersion)
+}
+
+func Test_ShouldUseDefaultTemplatesWhenSettingsHaveNone(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+	branchFrom(t, worktree, "feature", initial)
+	head := commitMultiple(t, worktree, "(+semver: minor) a feature\n")
+
+	settings := &igit.Settings{
+		MajorPattern: "\\+semver:\\s?(breaking|major)",
+		MinorPattern: "\\+semver:\\s?(feature|minor)",
+		PatchPattern: "\\+semver:\\s?(fix|patch)",
+	}
+	branchSettings := &igit.BranchSettings{IgnoreEnvVars: true}
+
+	// Act
+	branchInfo, branchErr := igit.GetCurrentVersionInfo(repository, settings, branchSettings, false)
+
+	err := worktree.Checkout(&git.CheckoutOptions{Hash: head})
+	assert.Nil(t, err)
+	err = repository.Storer.SetReference(plumbing.NewHashReference("refs/pull/4/head", head))
+	assert.Nil(t, err)
+
+	pullRequestInfo, pullRequestErr := igit.GetCurrentVersionInfo(repository, settings, branchSettings, false)
+
+	// Assert
+	assert.Nil(t, branchErr)
+	assert.Equal(t, "0.1.0-feature-0-"+head.String()[:4], branchInfo.Version.String())
+
+	assert.Nil(t, pullRequestErr)
+	assert.Equal(t, "0.1.0-pr.4.0", pullRequestInfo.Version.String())
+}
+
+func Test_ShouldLabelPullRequestWithSourceBranch(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+	branchFrom(t, worktree, "feature/a-branch", initial)
+	head := commitMultiple(t, worktree, "(+semver: minor) a feature\n")
+
+	err := worktree.Checkout(&git.CheckoutOptions{Hash: head})
+	assert.Nil(t, err)
+	err = repository.Storer.SetReference(plumbing.NewHashReference("refs/pull/4/head", head))
+	assert.Nil(t, err)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{TrimBranchPrefix: true}
+
+	os.Setenv("TRAVIS_PULL_REQUEST", "4")
+	os.Setenv("TRAVIS_PULL_REQUEST_BRANCH", "feature/a-branch")
+	os.Setenv("TRAVIS_BRANCH", "master")
+	defer os.Unsetenv("TRAVIS_PULL_REQUEST")
+	defer os.Unsetenv("TRAVIS_PULL_REQUEST_BRANCH")
+	defer os.Unsetenv("TRAVIS_BRANCH")
+
+	// Act
+	label, err := igit.GetPrereleaseLabel(repository, settings, branchSettings)
+	refLabel, refErr := igit.GetPrereleaseLabel(repository, settings, &igit.BranchSettings{IgnoreEnvVars: true})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "a-branch", label)
+
+	assert.Nil(t, refErr)
+	assert.Equal(t, "pr-4", refLabel)
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// RangeCommit is a commit in a range along with the bumps its message asks for
+type RangeCommit struct {
+	Hash    string
+	Message string
+	Merge   bool
+	Ignored bool
+	Bumps   []Bump
+}
+
+// CommitRange holds the commits reachable from the end of a range but not from its start, newest
+// first, and the bump that merging them into master would make.
+type CommitRange struct {
+	Commits []*RangeCommit
+	Bump    Bump
+}
+
+// GetCommitRange returns the commits reachable from the revision to but not from the revision from,
+// as listed by git log from..to
+func GetCommitRange(r *git.Repository, from string, to string, settings *Settings) (*CommitRange, error) {
+	settings, err := settings.compiled()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCommitRange failed")
+	}
+
+	fromCommit, err := resolveCommit(r, from)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCommitRange failed")
+	}
+
+	toCommit, err := resolveCommit(r, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCommitRange failed")
+	}
+
+	commits, versions, bump, err := walkRange(r, toCommit, fromCommit, settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCommitRange failed")
+	}
+
+	commitRange := &CommitRange{Commits: []*RangeCommit{}, Bump: bump}
+	for index, commit := range commits {
+		rangeCommit := &RangeCommit{
+			Hash:    commit.Hash.String(),
+			Message: commit.Message,
+			Merge:   commit.NumParents() > 1,
+			Ignored: versions[index].Ignored,
+			Bumps:   []Bump{},
+		}
+		if !rangeCommit.Ignored {
+			rangeCommit.Bumps = settings.matchBumps(commit.Message)
+		}
+		commitRange.Commits = append(commitRange.Commits, rangeCommit)
+	}
+
+	return commitRange, nil
+}
+
+// walkRange returns the commits reachable from to but not from from, newest first, the version
+// map entry of each and the bump that merging them into master would make. The settings must
+// already be compiled.
+func walkRange(r *git.Repository, to *object.Commit, from *object.Commit, settings *Settings) ([]*object.Commit, []*gitVersion, Bump, error) {
+	commits, err := commitsBetween(to, from)
+	if err != nil {
+		return nil, nil, BumpDefault, err
+	}
+
+	walker := newBranchWalker(r, to, map[string]string{}, settings, true, "", nil, false)
+	versions := []*gitVersion{}
+	for _, commit := range commits {
+		versions = append(versions, walker.getCommitVersion(commit))
+	}
+
+	if len(commits) == 0 {
+		return commits, versions, BumpNone, nil
+	}
+
+	combined := &gitVersion{}
+	walker.combineBumps(combined, versions)
+	return commits, versions, combined.bump(), nil
+}
+
+// bump returns the bump a version map entry makes
+func (v *gitVersion) bump() Bump {
+	switch {
+	case v.MajorBump:
+		return BumpMajor
+	case v.MinorBump:
+		return BumpMinor
+	case v.PatchBump:
+		return BumpPatch
+	case v.NoBump, v.Ignored:
+		return BumpNone
+	default:
+		return BumpDefault
+	}
+}
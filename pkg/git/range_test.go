@@ -0,0 +1,95 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	igit "github.com/syncromatics/gogitver/pkg/git"
+)
+
+func Test_ShouldListCommitsInRange(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature", initial)
+	feature := commitMultiple(t, worktree, "(+semver: fix) a fix\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	master := commitMultiple(t, worktree, "(+semver: major) on master\n")
+
+	checkout(t, worktree, "refs/heads/feature", false)
+	merged := commitMerge(t, worktree, "Merge master into feature\n", feature, master)
+	head := commitMultiple(t, worktree, "(+semver: feature) a feature\n")
+
+	// Act
+	commitRange, err := igit.GetCommitRange(repository, "master", "feature", igit.GetDefaultSettings())
+	assert.Nil(t, err)
+
+	// Assert
+	hashes := []string{}
+	for _, commit := range commitRange.Commits {
+		hashes = append(hashes, commit.Hash)
+	}
+	assert.ElementsMatch(t, []string{head.String(), merged.String(), feature.String()}, hashes)
+	assert.Equal(t, igit.BumpMinor, commitRange.Bump)
+}
+
+func Test_ShouldDefaultRangeToPatchBump(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature", initial)
+	commitMultiple(t, worktree, "some text\n", "(+semver: none) docs\n")
+
+	// Act
+	commitRange, err := igit.GetCommitRange(repository, "master", "feature", igit.GetDefaultSettings())
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Len(t, commitRange.Commits, 2)
+	assert.Equal(t, igit.BumpPatch, commitRange.Bump)
+}
+
+func Test_ShouldReportNoBumpForEmptyRange(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+	commitMultiple(t, worktree, "Initial commit")
+
+	// Act
+	commitRange, err := igit.GetCommitRange(repository, "master", "master", igit.GetDefaultSettings())
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Empty(t, commitRange.Commits)
+	assert.Equal(t, igit.BumpNone, commitRange.Bump)
+}
+
+func Test_ShouldResolveRangeOnDetachedCheckoutWithoutMaster(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+	err := repository.Storer.SetReference(plumbing.NewHashReference("refs/remotes/origin/main", initial))
+	assert.Nil(t, err)
+
+	head := commitMultiple(t, worktree, "(+semver: feature) a feature\n")
+	err = repository.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head))
+	assert.Nil(t, err)
+	err = repository.Storer.RemoveReference("refs/heads/master")
+	assert.Nil(t, err)
+
+	// Act
+	commitRange, err := igit.GetCommitRange(repository, "origin/main", "HEAD", igit.GetDefaultSettings())
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Len(t, commitRange.Commits, 1)
+	assert.Equal(t, head.String(), commitRange.Commits[0].Hash)
+	assert.Equal(t, igit.BumpMinor, commitRange.Bump)
+}
@@ -0,0 +1,319 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	igit "github.com/syncromatics/gogitver/pkg/git"
+)
+
+func Test_ShouldReconcileMergesDeterministically(t *testing.T) {
+	fixtures := []struct {
+		name     string
+		build    func(t *testing.T, repository *git.Repository, worktree *git.Worktree)
+		expected string
+	}{
+		{
+			name: "octopus merge takes the highest bump of all merged branches",
+			build: func(t *testing.T, repository *git.Repository, worktree *git.Worktree) {
+				initial := commitMultiple(t, worktree, "Initial commit")
+
+				branchFrom(t, worktree, "a", initial)
+				a := commitMultiple(t, worktree, "(+semver: minor)\n")
+
+				branchFrom(t, worktree, "b", initial)
+				b := commitMultiple(t, worktree, "(+semver: patch)\n")
+
+				branchFrom(t, worktree, "c", initial)
+				c := commitMultiple(t, worktree, "(+semver: major)\n")
+
+				checkout(t, worktree, "refs/heads/master", false)
+				commitMerge(t, worktree, "Merge branches a, b and c\n", initial, a, b, c)
+			},
+			expected: "1.0.0",
+		},
+		{
+			name: "octopus merge of patches bumps patch once",
+			build: func(t *testing.T, repository *git.Repository, worktree *git.Worktree) {
+				initial := commitMultiple(t, worktree, "Initial commit")
+
+				branchFrom(t, worktree, "a", initial)
+				a := commitMultiple(t, worktree, "(+semver: patch)\n", "some text\n")
+
+				branchFrom(t, worktree, "b", initial)
+				b := commitMultiple(t, worktree, "(+semver: patch)\n")
+
+				checkout(t, worktree, "refs/heads/master", false)
+				commitMerge(t, worktree, "Merge branches a and b\n", initial, a, b)
+			},
+			expected: "0.0.2",
+		},
+		{
+			name: "master merged back into a branch is not counted again",
+			build: func(t *testing.T, repository *git.Repository, worktree *git.Worktree) {
+				initial := commitMultiple(t, worktree, "Initial commit")
+
+				branchFrom(t, worktree, "x", initial)
+				x := commitMultiple(t, worktree, "(+semver: patch)\n")
+
+				checkout(t, worktree, "refs/heads/master", false)
+				master := commitMultiple(t, worktree, "(+semver: major)\n")
+
+				checkout(t, worktree, "refs/heads/x", false)
+				x = commitMerge(t, worktree, "Merge master into x\n", x, master)
+
+				checkout(t, worktree, "refs/heads/master", false)
+				commitMerge(t, worktree, "Merge x\n", master, x)
+			},
+			expected: "1.0.1",
+		},
+		{
+			name: "branch merged twice only counts new commits the second time",
+			build: func(t *testing.T, repository *git.Repository, worktree *git.Worktree) {
+				initial := commitMultiple(t, worktree, "Initial commit")
+
+				branchFrom(t, worktree, "x", initial)
+				x := commitMultiple(t, worktree, "(+semver: major)\n")
+
+				checkout(t, worktree, "refs/heads/master", false)
+				master := commitMerge(t, worktree, "Merge x\n", initial, x)
+
+				checkout(t, worktree, "refs/heads/x", false)
+				x = commitMultiple(t, worktree, "(+semver: patch)\n")
+
+				checkout(t, worktree, "refs/heads/master", false)
+				commitMerge(t, worktree, "Merge x again\n", master, x)
+			},
+			expected: "1.0.1",
+		},
+		{
+			name: "criss-cross merges attribute shared commits to the earliest merge",
+			build: func(t *testing.T, repository *git.Repository, worktree *git.Worktree) {
+				initial := commitMultiple(t, worktree, "Initial commit")
+
+				branchFrom(t, worktree, "a", initial)
+				a := commitMultiple(t, worktree, "(+semver: minor)\n")
+
+				branchFrom(t, worktree, "b", initial)
+				b := commitMultiple(t, worktree, "(+semver: patch)\n")
+
+				checkout(t, worktree, "refs/heads/a", false)
+				crossA := commitMerge(t, worktree, "Merge b into a\n", a, b)
+
+				checkout(t, worktree, "refs/heads/b", false)
+				crossB := commitMerge(t, worktree, "Merge a into b\n", b, a)
+
+				checkout(t, worktree, "refs/heads/master", false)
+				master := commitMerge(t, worktree, "Merge a\n", initial, crossA)
+				commitMerge(t, worktree, "Merge b\n", master, crossB)
+			},
+			expected: "0.1.1",
+		},
+		{
+			name: "branch forked before the latest tag does not count released commits",
+			build: func(t *testing.T, repository *git.Repository, worktree *git.Worktree) {
+				commitMultiple(t, worktree, "Initial commit")
+				major := commitMultiple(t, worktree, "(+semver: major)\n")
+
+				branchFrom(t, worktree, "b", major)
+				b := commitMultiple(t, worktree, "some text\n")
+
+				checkout(t, worktree, "refs/heads/master", false)
+				release := commitMultiple(t, worktree, "release\n")
+				tag(t, repository, "v1.0.0", release)
+
+				commitMerge(t, worktree, "Merge b\n", release, b)
+			},
+			expected: "1.0.1",
+		},
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			// Arrange
+			repository, worktree := initRepository(t)
+			fixture.build(t, repository, worktree)
+
+			settings := igit.GetDefaultSettings()
+			branchSettings := &igit.BranchSettings{
+				IgnoreEnvVars: true,
+			}
+
+			for i := 0; i < 10; i++ {
+				// Act
+				version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+				assert.Nil(t, err)
+
+				// Assert
+				assert.Equal(t, fixture.expected, version)
+			}
+		})
+	}
+}
+
+func tag(t *testing.T, repository *git.Repository, name string, hash plumbing.Hash) {
+	err := repository.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName("refs/tags/"+name), hash))
+	assert.Nil(t, err)
+}
+
+func branchFrom(t *testing.T, worktree *git.Worktree, branch string, hash plumbing.Hash) {
+	err := worktree.Checkout(&git.CheckoutOptions{
+		Create: true,
+		Branch: plumbing.ReferenceName("refs/heads/" + branch),
+		Hash:   hash,
+	})
+	assert.Nil(t, err)
+}
+
+func Test_ShouldReconcileMergesInFeatureBranch(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature", initial)
+	feature := commitMultiple(t, worktree, "some text\n")
+
+	branchFrom(t, worktree, "nested", feature)
+	nested := commitMultiple(t, worktree, "(+semver: minor)\n", "some more text\n")
+
+	checkout(t, worktree, "refs/heads/feature", false)
+	head := commitMerge(t, worktree, "Merge nested into feature\n", feature, nested)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "0.1.0-feature-1-"+head.String()[:4], version)
+}
+
+func Test_ShouldNotBumpFeatureBranchForMergingMaster(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "Initial commit")
+
+	branchFrom(t, worktree, "feature", initial)
+	feature := commitMultiple(t, worktree, "(+semver: patch)\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	master := commitMultiple(t, worktree, "(+semver: major)\n", "(+semver: minor)\n")
+
+	checkout(t, worktree, "refs/heads/feature", false)
+	head := commitMerge(t, worktree, "Merge master into feature\n", feature, master)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "1.1.1-feature-1-"+head.String()[:4], version)
+}
+
+func Test_ShouldNotCountReleasedCommitsMergedIntoFeatureBranch(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree, "Initial commit")
+	major := commitMultiple(t, worktree, "(+semver: major)\n")
+
+	branchFrom(t, worktree, "b", major)
+	b := commitMultiple(t, worktree, "some text\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	release := commitMultiple(t, worktree, "release\n")
+	tag(t, repository, "v1.0.0", release)
+
+	branchFrom(t, worktree, "feature", release)
+	head := commitMerge(t, worktree, "Merge b into feature\n", release, b)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "1.0.0-feature-0-"+head.String()[:4], version)
+}
+
+func Test_ShouldNotCountMasterCommitsMergedIntoFeatureBranchForkedBeforeTag(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	initial := commitMultiple(t, worktree, "(+semver: minor) Initial commit\n")
+
+	branchFrom(t, worktree, "feature", initial)
+	feature := commitMultiple(t, worktree, "some text\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	major := commitMultiple(t, worktree, "(+semver: major)\n")
+	release := commitMultiple(t, worktree, "release\n")
+	tag(t, repository, "v1.0.0", release)
+
+	branchFrom(t, worktree, "b", major)
+	b := commitMultiple(t, worktree, "some more text\n")
+
+	checkout(t, worktree, "refs/heads/feature", false)
+	head := commitMerge(t, worktree, "Merge b into feature\n", feature, b)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "1.0.0-feature-1-"+head.String()[:4], version)
+}
+
+func Test_ShouldStopBranchWalkAtMasterMergedAfterRelease(t *testing.T) {
+	// Arrange
+	repository, worktree := initRepository(t)
+
+	commitMultiple(t, worktree, "(+semver: major) old major\n", "(+semver: minor) old minor\n")
+	fork := commitMultiple(t, worktree, "fork point\n")
+
+	branchFrom(t, worktree, "feature", fork)
+	feature := commitMultiple(t, worktree, "some text\n")
+
+	checkout(t, worktree, "refs/heads/master", false)
+	release := commitMultiple(t, worktree, "release\n")
+	tag(t, repository, "v5.0.0", release)
+	master := commitMultiple(t, worktree, "after the release\n")
+
+	checkout(t, worktree, "refs/heads/feature", false)
+	head := commitMerge(t, worktree, "Merge master into feature\n", feature, master)
+
+	settings := igit.GetDefaultSettings()
+	branchSettings := &igit.BranchSettings{
+		IgnoreEnvVars: true,
+	}
+
+	// Act
+	version, err := igit.GetCurrentVersion(repository, settings, branchSettings, false)
+	assert.Nil(t, err)
+
+	// Assert
+	assert.Equal(t, "5.0.1-feature-1-"+head.String()[:4], version)
+}
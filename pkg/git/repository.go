@@ -0,0 +1,247 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	billy "gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// SettingsFileName is the name of the settings file looked for in the repository root
+const SettingsFileName = ".gogitver.yaml"
+
+// FindRepositoryRoot walks up from path to the first directory that contains a .git entry.
+// If there is none, path itself is returned.
+func FindRepositoryRoot(path string) (string, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrap(err, "FindRepositoryRoot failed")
+	}
+
+	for dir := absolute; ; dir = filepath.Dir(dir) {
+		_, err := os.Stat(filepath.Join(dir, ".git"))
+		if err == nil {
+			return dir, nil
+		}
+
+		if filepath.Dir(dir) == dir {
+			return absolute, nil
+		}
+	}
+}
+
+// OpenRepository opens the repository that contains path, which may be any directory of a working
+// tree, of a linked worktree created by git worktree add, or a bare repository. When gitDir is given
+// it is used as the git directory instead, with path as its working tree; an empty path then opens
+// the repository without a working tree.
+func OpenRepository(path string, gitDir string) (*git.Repository, error) {
+	dot, root, err := locateGitDir(path, gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var worktree billy.Filesystem
+	if root != "" {
+		worktree = osfs.New(root)
+	}
+	return openGitDir(dot, worktree)
+}
+
+// HooksDir returns the directory git runs the hooks of the repository that contains path from,
+// which is found the same way as by OpenRepository. It is the directory set by core.hooksPath or
+// otherwise the hooks directory of the git directory, which linked worktrees share.
+func HooksDir(path string, gitDir string) (string, error) {
+	dot, root, err := locateGitDir(path, gitDir)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := OpenRepository(path, gitDir)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := r.Config()
+	if err != nil {
+		return "", errors.Wrap(err, "cannot read the repository config")
+	}
+
+	hooksPath := config.Raw.Section("core").Option("hooksPath")
+	if hooksPath == "" {
+		common, err := commonDir(dot)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(common, "hooks"), nil
+	}
+
+	if strings.HasPrefix(hooksPath, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, "cannot expand core.hooksPath")
+		}
+		hooksPath = filepath.Join(home, hooksPath[2:])
+	}
+
+	// like git, a relative hooks path is relative to the root of the working tree, or to the git
+	// directory of a bare repository
+	if !filepath.IsAbs(hooksPath) {
+		base := root
+		if base == "" {
+			base = dot
+		}
+		hooksPath = filepath.Join(base, hooksPath)
+	}
+	return filepath.Abs(hooksPath)
+}
+
+// locateGitDir returns the git directory of the repository that contains path and the root of its
+// working tree, which is empty for a bare repository. A gitDir that is given is used as it is.
+func locateGitDir(path string, gitDir string) (string, string, error) {
+	if gitDir != "" {
+		return gitDir, path, nil
+	}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot locate the repository")
+	}
+
+	for dir := absolute; ; dir = filepath.Dir(dir) {
+		dotGit := filepath.Join(dir, git.GitDirName)
+		info, err := os.Stat(dotGit)
+		switch {
+		case err == nil && info.IsDir():
+			return dotGit, dir, nil
+		case err == nil:
+			linked, err := readGitFile(dotGit)
+			return linked, dir, err
+		case isGitDir(dir):
+			return dir, "", nil
+		}
+
+		if filepath.Dir(dir) == dir {
+			return "", "", git.ErrRepositoryNotExists
+		}
+	}
+}
+
+// openGitDir opens the git directory at path. The git directory of a linked worktree only holds
+// the files of that worktree and names the directory with the rest of the repository in its
+// commondir file.
+func openGitDir(path string, worktree billy.Filesystem) (*git.Repository, error) {
+	if !isGitDir(path) {
+		return nil, git.ErrRepositoryNotExists
+	}
+
+	common, err := commonDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var dot billy.Filesystem = osfs.New(path)
+	if common != path {
+		dot = newLinkedWorktreeFilesystem(dot, osfs.New(common))
+	}
+
+	return git.Open(filesystem.NewStorage(dot, cache.NewObjectLRUDefault()), worktree)
+}
+
+// commonDir returns the directory named by the commondir file of a git directory, or the git
+// directory itself when it has none.
+func commonDir(path string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(path, "commondir"))
+	if os.IsNotExist(err) {
+		return path, nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "cannot read the commondir file")
+	}
+
+	dir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(path, dir)
+	}
+	return dir, nil
+}
+
+// readGitFile returns the git directory named by a .git file, as written for linked worktrees and
+// submodules.
+func readGitFile(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", path)
+	}
+
+	const prefix = "gitdir: "
+	line := strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0])
+	if !strings.HasPrefix(line, prefix) {
+		return "", errors.Errorf("%s does not name a git directory", path)
+	}
+
+	dir := strings.TrimSpace(line[len(prefix):])
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return dir, nil
+}
+
+// isGitDir reports whether path looks like a git directory, the same way git does: it has a HEAD
+// and an objects and refs directory, either of its own or in its commondir.
+func isGitDir(path string) bool {
+	_, err := os.Stat(filepath.Join(path, "HEAD"))
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(filepath.Join(path, "commondir"))
+	if err == nil {
+		return true
+	}
+
+	for _, dir := range []string{"objects", "refs"} {
+		info, err := os.Stat(filepath.Join(path, dir))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSettingsFileFromRevision returns the settings file stored at name in the tree of the given
+// revision, or nil if that revision does not contain it.
+func GetSettingsFileFromRevision(r *git.Repository, revision string, name string) (*SettingsFile, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve revision '%s'", revision)
+	}
+
+	commit, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get commit for revision '%s'", revision)
+	}
+
+	file, err := commit.File(filepath.ToSlash(name))
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get '%s' at revision '%s'", name, revision)
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read '%s' at revision '%s'", name, revision)
+	}
+
+	return &SettingsFile{Name: revision + ":" + name, Content: []byte(content)}, nil
+}
@@ -0,0 +1,265 @@
+package git_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+
+	igit "github.com/syncromatics/gogitver/pkg/git"
+)
+
+func TestFindRepositoryRootWalksUpFromSubdirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogitver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "a", "b")
+	err = os.MkdirAll(sub, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := igit.FindRepositoryRoot(sub)
+	assert.Nil(t, err)
+
+	expected, _ := filepath.Abs(dir)
+	assert.Equal(t, expected, root)
+}
+
+func TestGetSettingsFileFromRevision(t *testing.T) {
+	fs := memfs.New()
+	r, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	util.WriteFile(fs, igit.SettingsFileName, []byte("major-version-bump-message: 'committed'\n"), 0644)
+	_, err = w.Add(igit.SettingsFileName)
+	assert.Nil(t, err)
+	_, err = w.Commit("add settings\n", &git.CommitOptions{Author: defaultSignature()})
+	assert.Nil(t, err)
+
+	util.WriteFile(fs, igit.SettingsFileName, []byte("major-version-bump-message: 'uncommitted'\n"), 0644)
+
+	file, err := igit.GetSettingsFileFromRevision(r, "HEAD", igit.SettingsFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "major-version-bump-message: 'committed'\n", string(file.Content))
+
+	file, err = igit.GetSettingsFileFromRevision(r, "HEAD", "missing.yaml")
+	assert.Nil(t, err)
+	assert.Nil(t, file)
+}
+
+func TestOpenRepositoryFromSubdirectory(t *testing.T) {
+	dir, head := initPlainRepository(t)
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, "a", "b")
+	err := os.MkdirAll(sub, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := igit.OpenRepository(sub, "")
+	assert.Nil(t, err)
+
+	ref, err := r.Head()
+	assert.Nil(t, err)
+	assert.Equal(t, head, ref.Hash())
+
+	_, err = r.Worktree()
+	assert.Nil(t, err)
+}
+
+func TestOpenRepositoryOfLinkedWorktree(t *testing.T) {
+	dir, head := initPlainRepository(t)
+	defer os.RemoveAll(dir)
+
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Storer.SetReference(plumbing.NewHashReference("refs/heads/feature", head))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the layout git worktree add creates
+	gitDir := filepath.Join(dir, ".git", "worktrees", "linked")
+	worktree := filepath.Join(dir, "linked")
+	writeFiles(t, map[string]string{
+		filepath.Join(gitDir, "HEAD"):      "ref: refs/heads/feature\n",
+		filepath.Join(gitDir, "commondir"): "../..\n",
+		filepath.Join(gitDir, "gitdir"):    filepath.Join(worktree, ".git") + "\n",
+		filepath.Join(worktree, ".git"):    "gitdir: " + gitDir + "\n",
+	})
+
+	linked, err := igit.OpenRepository(worktree, "")
+	assert.Nil(t, err)
+
+	ref, err := linked.Head()
+	assert.Nil(t, err)
+	assert.Equal(t, plumbing.ReferenceName("refs/heads/feature"), ref.Name())
+	assert.Equal(t, head, ref.Hash())
+
+	_, err = linked.Reference("refs/heads/master", true)
+	assert.Nil(t, err)
+}
+
+func TestOpenRepositoryOfBareRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogitver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = git.PlainInit(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := igit.OpenRepository(dir, "")
+	assert.Nil(t, err)
+
+	_, err = r.Worktree()
+	assert.Equal(t, git.ErrIsBareRepository, err)
+}
+
+func TestOpenRepositoryWithGitDir(t *testing.T) {
+	dir, head := initPlainRepository(t)
+	defer os.RemoveAll(dir)
+
+	r, err := igit.OpenRepository("", filepath.Join(dir, ".git"))
+	assert.Nil(t, err)
+
+	ref, err := r.Head()
+	assert.Nil(t, err)
+	assert.Equal(t, head, ref.Hash())
+
+	_, err = r.Worktree()
+	assert.Equal(t, git.ErrIsBareRepository, err)
+
+	r, err = igit.OpenRepository(dir, filepath.Join(dir, ".git"))
+	assert.Nil(t, err)
+
+	_, err = r.Worktree()
+	assert.Nil(t, err)
+}
+
+func TestOpenRepositoryOutsideRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogitver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = igit.OpenRepository(dir, "")
+	assert.Equal(t, git.ErrRepositoryNotExists, err)
+
+	_, err = igit.OpenRepository("", dir)
+	assert.Equal(t, git.ErrRepositoryNotExists, err)
+}
+
+func initPlainRepository(t *testing.T) (string, plumbing.Hash) {
+	dir, err := ioutil.TempDir("", "gogitver")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := w.Commit("Initial commit\n", &git.CommitOptions{Author: defaultSignature()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, head
+}
+
+func writeFiles(t *testing.T, files map[string]string) {
+	for path, content := range files {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHooksDir(t *testing.T) {
+	dir, head := initPlainRepository(t)
+	defer os.RemoveAll(dir)
+
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Storer.SetReference(plumbing.NewHashReference("refs/heads/feature", head))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gitDir := filepath.Join(dir, ".git", "worktrees", "linked")
+	worktree := filepath.Join(dir, "linked")
+	writeFiles(t, map[string]string{
+		filepath.Join(gitDir, "HEAD"):      "ref: refs/heads/feature\n",
+		filepath.Join(gitDir, "commondir"): "../..\n",
+		filepath.Join(worktree, ".git"):    "gitdir: " + gitDir + "\n",
+	})
+
+	hooks, err := igit.HooksDir(dir, "")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks"), hooks)
+
+	// linked worktrees share the hooks of the main worktree
+	hooks, err = igit.HooksDir(filepath.Join(worktree, "sub"), "")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks"), hooks)
+
+	config, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Raw.Section("core").SetOption("hooksPath", ".githooks")
+	err = r.Storer.SetConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, err = igit.HooksDir(dir, "")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, ".githooks"), hooks)
+
+	hooks, err = igit.HooksDir(worktree, "")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(worktree, ".githooks"), hooks)
+}
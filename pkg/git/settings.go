@@ -1,42 +1,150 @@
 package git
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
+	"regexp"
 
 	"github.com/pkg/errors"
-	"gopkg.in/yaml.v2"
 )
 
 // Settings provides the regex patterns used for version bumping
 type Settings struct {
-	MajorPattern string `yaml:"major-version-bump-message"`
-	MinorPattern string `yaml:"minor-version-bump-message"`
-	PatchPattern string `yaml:"patch-version-bump-message"`
+	MajorPattern  string `yaml:"major-version-bump-message"`
+	MinorPattern  string `yaml:"minor-version-bump-message"`
+	PatchPattern  string `yaml:"patch-version-bump-message"`
+	NoBumpPattern string `yaml:"no-bump-message"`
+
+	NextVersion string `yaml:"next-version,omitempty"`
+	DirtyMarker string `yaml:"dirty-marker"`
+
+	PrereleaseTemplate            string `yaml:"prerelease-template"`
+	PullRequestPrereleaseTemplate string `yaml:"pull-request-prerelease-template"`
+
+	Ignore      IgnoreSettings    `yaml:"ignore,omitempty"`
+	DockerTags  DockerTagSettings `yaml:"docker-tags"`
+	UpdateFiles []UpdateFile      `yaml:"update-files,omitempty"`
+
+	majorRegexp  *regexp.Regexp
+	minorRegexp  *regexp.Regexp
+	patchRegexp  *regexp.Regexp
+	noBumpRegexp *regexp.Regexp
 }
 
-// GetSettingsFromFile provides a settings object by parsing the yaml from the file provided
+// GetSettingsFromFile provides a settings object by parsing the yaml from the file provided.
+// Keys missing from the file keep their default values.
 func GetSettingsFromFile(file io.Reader) (*Settings, error) {
-	s := Settings{}
-
 	fileBytes, err := ioutil.ReadAll(file)
 	if err != nil {
 		return nil, errors.Wrap(err, "read bytes from file failed")
 	}
 
-	err = yaml.Unmarshal(fileBytes, &s)
+	return LoadSettings(&SettingsLayers{
+		Files: []*SettingsFile{{Content: fileBytes}},
+	})
+}
+
+// GetDefaultSettings returns the default settings
+func GetDefaultSettings() *Settings {
+	s := &Settings{
+		MajorPattern:                  "\\+semver:\\s?(breaking|major)",
+		MinorPattern:                  "\\+semver:\\s?(feature|minor)",
+		PatchPattern:                  "\\+semver:\\s?(fix|patch)",
+		NoBumpPattern:                 "\\+semver:\\s?(none|skip)",
+		DirtyMarker:                   DirtyMarkerNone,
+		PrereleaseTemplate:            DefaultPrereleaseTemplate,
+		PullRequestPrereleaseTemplate: DefaultPullRequestPrereleaseTemplate,
+		DockerTags: DockerTagSettings{
+			Latest:   true,
+			Floating: true,
+		},
+	}
+
+	err := s.compile()
 	if err != nil {
-		return nil, errors.Wrap(err, "read yaml from file failed")
+		panic(err)
 	}
 
-	return &s, nil
+	return s
 }
 
-// GetDefaultSettings returns the default settings
-func GetDefaultSettings() *Settings {
-	return &Settings{
-		MajorPattern: "\\+semver:\\s?(breaking|major)",
-		MinorPattern: "\\+semver:\\s?(feature|minor)",
-		PatchPattern: "\\+semver:\\s?(fix|patch)",
+type pattern struct {
+	key      string
+	value    string
+	compiled **regexp.Regexp
+	optional bool
+}
+
+func (s *Settings) patterns() []pattern {
+	return []pattern{
+		{"major-version-bump-message", s.MajorPattern, &s.majorRegexp, false},
+		{"minor-version-bump-message", s.MinorPattern, &s.minorRegexp, false},
+		{"patch-version-bump-message", s.PatchPattern, &s.patchRegexp, false},
+		{"no-bump-message", s.NoBumpPattern, &s.noBumpRegexp, true},
+	}
+}
+
+// compile validates the settings and builds the regular expressions they match messages with.
+// GetDefaultSettings and LoadSettings compile the settings they return.
+func (s *Settings) compile() error {
+	errs := s.validate()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// compiled returns settings that are ready to match messages with. Settings that were not loaded
+// through GetDefaultSettings or LoadSettings are compiled into a copy, leaving the caller's as
+// they are.
+func (s *Settings) compiled() (*Settings, error) {
+	if s.majorRegexp != nil {
+		return s, nil
+	}
+
+	c := *s
+	err := c.compile()
+	if err != nil {
+		return nil, err
 	}
+	return &c, nil
+}
+
+// validate compiles every pattern and reports the ones that are empty or invalid.
+func (s *Settings) validate() SettingsErrors {
+	errs := SettingsErrors{}
+	for _, p := range s.patterns() {
+		if p.value == "" && p.optional {
+			*p.compiled = nil
+			continue
+		}
+
+		if p.value == "" {
+			errs = append(errs, &SettingsError{Key: p.key, Message: "pattern must not be empty"})
+			continue
+		}
+
+		compiled, err := regexp.Compile(p.value)
+		if err != nil {
+			errs = append(errs, &SettingsError{Key: p.key, Message: fmt.Sprintf("invalid pattern: %s", err)})
+			continue
+		}
+		*p.compiled = compiled
+	}
+
+	if s.NextVersion != "" {
+		_, err := parseTag(s.NextVersion)
+		if err != nil {
+			errs = append(errs, &SettingsError{Key: "next-version", Message: fmt.Sprintf("invalid version: %s", err)})
+		}
+	}
+
+	errs = append(errs, validateDirtyMarker(s.DirtyMarker)...)
+	errs = append(errs, validatePrereleaseTemplate("prerelease-template", s.PrereleaseTemplate, templatePlaceholders[:3])...)
+	errs = append(errs, validatePrereleaseTemplate("pull-request-prerelease-template", s.PullRequestPrereleaseTemplate, templatePlaceholders)...)
+	errs = append(errs, s.Ignore.validate()...)
+	errs = append(errs, s.DockerTags.validate()...)
+	errs = append(errs, validateUpdateFiles(s.UpdateFiles)...)
+	return errs
 }
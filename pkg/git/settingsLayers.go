@@ -0,0 +1,267 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvironmentPrefix is the prefix of environment variables that override settings
+const EnvironmentPrefix = "GOGITVER_"
+
+// SettingsFile is a yaml document that provides a layer of settings
+type SettingsFile struct {
+	Name    string
+	Content []byte
+}
+
+// SettingsLayers holds the sources settings are merged from. Each layer overrides only the keys
+// it sets, in order of increasing precedence: the defaults, each file in order, environment
+// variables and finally the overrides.
+type SettingsLayers struct {
+	Files     []*SettingsFile
+	Environ   []string
+	Overrides map[string]string
+}
+
+// SettingsError describes a single problem found while validating settings
+type SettingsError struct {
+	Source  string
+	Line    int
+	Key     string
+	Message string
+}
+
+func (e *SettingsError) Error() string {
+	switch {
+	case e.Source != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s: %s", e.Source, e.Line, e.Key, e.Message)
+	case e.Source != "":
+		return fmt.Sprintf("%s: %s: %s", e.Source, e.Key, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Key, e.Message)
+	default:
+		return fmt.Sprintf("%s: %s", e.Key, e.Message)
+	}
+}
+
+// SettingsErrors is returned when settings fail validation and holds every problem found
+type SettingsErrors []*SettingsError
+
+func (e SettingsErrors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// SettingsKeys returns the keys that can be set by environment variables and overrides. These are
+// the keys with a single value; lists and nested settings can only be set in settings files.
+func SettingsKeys() []string {
+	keys := []string{}
+	t := reflect.TypeOf(Settings{})
+	for key, index := range settingsFields() {
+		switch t.Field(index).Type.Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Map, reflect.Ptr:
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EnvironmentVariable returns the name of the environment variable that overrides a settings key
+func EnvironmentVariable(key string) string {
+	return EnvironmentPrefix + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// settingsOrigin records the layer that last set a key so problems can be reported against it.
+type settingsOrigin struct {
+	source  string
+	content []byte
+}
+
+// LoadSettings merges the settings layers over the default settings and validates the result
+func LoadSettings(layers *SettingsLayers) (*Settings, error) {
+	s := GetDefaultSettings()
+	origins := map[string]*settingsOrigin{}
+	settingsErrors := SettingsErrors{}
+
+	for _, file := range layers.Files {
+		keys := yaml.MapSlice{}
+		err := yaml.Unmarshal(file.Content, &keys)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read yaml from %s failed", describeFile(file))
+		}
+
+		err = yaml.Unmarshal(file.Content, s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read yaml from %s failed", describeFile(file))
+		}
+
+		for _, key := range unknownKeys(keys, reflect.TypeOf(Settings{}), "") {
+			settingsErrors = append(settingsErrors, &SettingsError{Source: file.Name, Line: findKeyLine(file.Content, key), Key: key, Message: "unknown key"})
+		}
+
+		for _, item := range keys {
+			origins[fmt.Sprint(item.Key)] = &settingsOrigin{source: file.Name, content: file.Content}
+		}
+	}
+
+	for _, variable := range layers.Environ {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], EnvironmentPrefix) {
+			continue
+		}
+
+		for _, key := range SettingsKeys() {
+			if EnvironmentVariable(key) == parts[0] {
+				origins[key] = &settingsOrigin{source: parts[0]}
+				err := setSettingsField(s, key, parts[1])
+				if err != nil {
+					settingsErrors = append(settingsErrors, &SettingsError{Source: parts[0], Key: key, Message: err.Error()})
+				}
+			}
+		}
+	}
+
+	overrides := []string{}
+	for key := range layers.Overrides {
+		overrides = append(overrides, key)
+	}
+	sort.Strings(overrides)
+
+	scalars := map[string]bool{}
+	for _, key := range SettingsKeys() {
+		scalars[key] = true
+	}
+
+	for _, key := range overrides {
+		source := "--" + key
+		if !scalars[key] {
+			settingsErrors = append(settingsErrors, &SettingsError{Source: source, Key: key, Message: "cannot be overridden"})
+			continue
+		}
+
+		origins[key] = &settingsOrigin{source: source}
+		err := setSettingsField(s, key, layers.Overrides[key])
+		if err != nil {
+			settingsErrors = append(settingsErrors, &SettingsError{Source: source, Key: key, Message: err.Error()})
+		}
+	}
+
+	for _, err := range s.validate() {
+		if origin, ok := origins[strings.Split(err.Key, ".")[0]]; ok {
+			err.Source = origin.source
+			err.Line = findKeyLine(origin.content, err.Key)
+		}
+		settingsErrors = append(settingsErrors, err)
+	}
+
+	if len(settingsErrors) > 0 {
+		return nil, settingsErrors
+	}
+
+	return s, nil
+}
+
+// settingsFields maps each settings key to the index of the field it is stored in.
+func settingsFields() map[string]int {
+	return yamlFields(reflect.TypeOf(Settings{}))
+}
+
+func yamlFields(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			fields[tag] = i
+		}
+	}
+	return fields
+}
+
+// unknownKeys returns the dotted paths of keys in a yaml mapping that have no field in the struct type t.
+func unknownKeys(keys yaml.MapSlice, t reflect.Type, prefix string) []string {
+	unknown := []string{}
+	fields := yamlFields(t)
+	for _, item := range keys {
+		key := prefix + fmt.Sprint(item.Key)
+		index, ok := fields[fmt.Sprint(item.Key)]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+
+		nested, ok := item.Value.(yaml.MapSlice)
+		if ok && t.Field(index).Type.Kind() == reflect.Struct {
+			unknown = append(unknown, unknownKeys(nested, t.Field(index).Type, key+".")...)
+		}
+	}
+	return unknown
+}
+
+func setSettingsField(s *Settings, key string, value string) error {
+	field := reflect.ValueOf(s).Elem().Field(settingsFields()[key])
+	if field.Kind() == reflect.String {
+		field.SetString(value)
+		return nil
+	}
+
+	err := yaml.Unmarshal([]byte(value), field.Addr().Interface())
+	if err != nil {
+		return errors.Wrapf(err, "invalid value '%s'", value)
+	}
+	return nil
+}
+
+func describeFile(file *SettingsFile) string {
+	if file.Name == "" {
+		return "file"
+	}
+	return file.Name
+}
+
+// findKeyLine returns the line number on which a key is declared, or 0 when it is absent. Nested
+// keys are given as dotted paths and are looked for within the block of their parent.
+func findKeyLine(content []byte, key string) int {
+	path := strings.Split(key, ".")
+	parentIndent := -1
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(text) - len(trimmed)
+		if indent <= parentIndent {
+			return 0 // left the block of the parent key
+		}
+		if parentIndent < 0 && indent > 0 {
+			continue
+		}
+
+		declaration := regexp.MustCompile(fmt.Sprintf(`^["']?%s["']?\s*:`, regexp.QuoteMeta(path[0])))
+		if !declaration.MatchString(trimmed) {
+			continue
+		}
+
+		if len(path) == 1 {
+			return line
+		}
+		path = path[1:]
+		parentIndent = indent
+	}
+	return 0
+}
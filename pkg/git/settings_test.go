@@ -2,6 +2,7 @@ package git_test
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/syncromatics/gogitver/pkg/git"
@@ -30,3 +31,219 @@ patch-version-bump-message: '\+semver:\s?(fix|patch)'
 
 	assert.Equal(t, "\\+semver:\\s?(fix|patch)", s.PatchPattern)
 }
+
+func TestSettingsParseInvalidPattern(t *testing.T) {
+	testString := `
+major-version-bump-message: '\+semver:\s?(breaking|major)'
+minor-version-bump-message: '\+semver:\s?(feature|minor'
+patch-version-bump-message: '\+semver:\s?(fix|patch)'
+`
+
+	r := bytes.NewReader([]byte(testString))
+
+	_, err := git.GetSettingsFromFile(r)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "minor-version-bump-message")
+}
+
+func TestSettingsParseReportsProblemsWithLineNumbers(t *testing.T) {
+	testString := `major-version-bump-message: '\+semver:\s?(breaking|major)'
+minor-version-bump-message: ''
+unknown-key: true
+`
+
+	r := bytes.NewReader([]byte(testString))
+
+	_, err := git.GetSettingsFromFile(r)
+
+	settingsErrors, ok := err.(git.SettingsErrors)
+	if !ok {
+		t.Fatalf("expected settings errors but got %v", err)
+	}
+
+	assert.Equal(t, git.SettingsErrors{
+		{Line: 3, Key: "unknown-key", Message: "unknown key"},
+		{Line: 2, Key: "minor-version-bump-message", Message: "pattern must not be empty"},
+	}, settingsErrors)
+}
+
+func TestSettingsParseInheritsDefaultsForMissingKeys(t *testing.T) {
+	testString := `
+major-version-bump-message: 'BREAKING CHANGE'
+`
+
+	r := bytes.NewReader([]byte(testString))
+
+	s, err := git.GetSettingsFromFile(r)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "BREAKING CHANGE", s.MajorPattern)
+	assert.Equal(t, git.GetDefaultSettings().MinorPattern, s.MinorPattern)
+	assert.Equal(t, git.GetDefaultSettings().PatchPattern, s.PatchPattern)
+}
+
+func TestLoadSettingsMergesLayersInOrder(t *testing.T) {
+	layers := &git.SettingsLayers{
+		Files: []*git.SettingsFile{
+			{
+				Name: "global.yaml",
+				Content: []byte(`
+major-version-bump-message: 'global-major'
+minor-version-bump-message: 'global-minor'
+patch-version-bump-message: 'global-patch'
+`),
+			},
+			{
+				Name: "repo.yaml",
+				Content: []byte(`
+minor-version-bump-message: 'repo-minor'
+patch-version-bump-message: 'repo-patch'
+`),
+			},
+		},
+		Environ: []string{
+			"GOGITVER_PATCH_VERSION_BUMP_MESSAGE=env-patch",
+			"GOGITVER_UNRELATED=value",
+		},
+		Overrides: map[string]string{
+			"major-version-bump-message": "flag-major",
+		},
+	}
+
+	s, err := git.LoadSettings(layers)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "flag-major", s.MajorPattern)
+	assert.Equal(t, "repo-minor", s.MinorPattern)
+	assert.Equal(t, "env-patch", s.PatchPattern)
+}
+
+func TestLoadSettingsReportsTheLayerThatSetAnInvalidValue(t *testing.T) {
+	layers := &git.SettingsLayers{
+		Files: []*git.SettingsFile{
+			{
+				Name:    "repo.yaml",
+				Content: []byte("major-version-bump-message: 'fine'\nminor-version-bump-message: '(x'\n"),
+			},
+		},
+		Environ: []string{
+			"GOGITVER_PATCH_VERSION_BUMP_MESSAGE=",
+		},
+	}
+
+	_, err := git.LoadSettings(layers)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "repo.yaml:2: minor-version-bump-message: invalid pattern: error parsing regexp: missing closing ): `(x`\n"+
+		"GOGITVER_PATCH_VERSION_BUMP_MESSAGE: patch-version-bump-message: pattern must not be empty", err.Error())
+}
+
+func TestSettingsParseValidatesIgnoreRules(t *testing.T) {
+	testString := `major-version-bump-message: '\+semver:\s?(breaking|major)'
+ignore:
+  messages:
+    - '^chore'
+    - '(release'
+  before: yesterday
+  committers: []
+`
+
+	r := bytes.NewReader([]byte(testString))
+
+	_, err := git.GetSettingsFromFile(r)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "line 7: ignore.committers: unknown key\n"+
+		"line 3: ignore.messages: invalid pattern in entry 1: error parsing regexp: missing closing ): `(release`\n"+
+		"line 6: ignore.before: invalid date 'yesterday', expected YYYY-MM-DD or RFC 3339", err.Error())
+}
+
+func TestSettingsParseKeepsDefaultDockerTagSettings(t *testing.T) {
+	// Arrange
+	settingsFile := `
+docker-tags:
+  latest: false
+  prefix: v
+`
+
+	// Act
+	settings, err := git.GetSettingsFromFile(bytes.NewBufferString(settingsFile))
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, git.DockerTagSettings{Latest: false, Floating: true, Prefix: "v"}, settings.DockerTags)
+}
+
+func TestSettingsParseValidatesDockerTagPrefix(t *testing.T) {
+	// Arrange
+	settingsFile := `
+docker-tags:
+  prefix: '-v'
+`
+
+	// Act
+	_, err := git.GetSettingsFromFile(bytes.NewBufferString(settingsFile))
+
+	// Assert
+	assert.EqualError(t, err, "line 3: docker-tags.prefix: '-v' is not a valid start of a docker tag")
+}
+
+func TestSettingsParseUpdateFiles(t *testing.T) {
+	// Arrange
+	settingsFile := `
+update-files:
+  - package.json
+  - path: build/version.props
+    type: msbuild
+  - type: npm
+`
+
+	// Act
+	settings, err := git.GetSettingsFromFile(bytes.NewBufferString(settingsFile))
+
+	// Assert
+	assert.Nil(t, settings)
+	assert.EqualError(t, err, "line 2: update-files: entry 2 must have a path")
+
+	settings, err = git.GetSettingsFromFile(bytes.NewBufferString(strings.Replace(settingsFile, "  - type: npm\n", "", 1)))
+	assert.Nil(t, err)
+	assert.Equal(t, []git.UpdateFile{{Path: "package.json"}, {Path: "build/version.props", Type: "msbuild"}}, settings.UpdateFiles)
+}
+
+func TestSettingsParseValidatesPrereleaseTemplates(t *testing.T) {
+	// Arrange
+	settingsFile := `
+prerelease-template: '{branch}.{number}'
+pull-request-prerelease-template: 'pr.{id}'
+`
+
+	// Act
+	_, err := git.GetSettingsFromFile(bytes.NewBufferString(settingsFile))
+
+	// Assert
+	assert.EqualError(t, err, "line 2: prerelease-template: unknown placeholder {number}, expected one of {branch}, {commits}, {sha}\n"+
+		"line 3: pull-request-prerelease-template: unknown placeholder {id}, expected one of {branch}, {commits}, {sha}, {number}, {source}, {target}")
+}
+
+func TestSettingsLiteralMatchesBumps(t *testing.T) {
+	s := &git.Settings{
+		MajorPattern: "\\+semver:\\s?(breaking|major)",
+		MinorPattern: "\\+semver:\\s?(feature|minor)",
+		PatchPattern: "\\+semver:\\s?(fix|patch",
+	}
+
+	_, err := s.GetMessageBumps("(+semver: minor) a feature")
+	assert.NotNil(t, err)
+
+	s.PatchPattern = "\\+semver:\\s?(fix|patch)"
+	bumps, err := s.GetMessageBumps("(+semver: minor) a feature")
+	assert.Nil(t, err)
+	assert.Equal(t, []git.Bump{git.BumpMinor}, bumps)
+}
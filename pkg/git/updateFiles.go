@@ -0,0 +1,38 @@
+package git
+
+import (
+	"fmt"
+)
+
+// UpdateFile is a file whose version field is rewritten by gogitver apply. In the settings file it
+// is either a path or a mapping with a path and the type of the file.
+type UpdateFile struct {
+	// Path is relative to the repository root
+	Path string `yaml:"path"`
+	// Type selects the updater, it is detected from the file name when empty
+	Type string `yaml:"type,omitempty"`
+}
+
+// UnmarshalYAML accepts a plain path as well as a mapping
+func (u *UpdateFile) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		u.Path = path
+		return nil
+	}
+
+	type plain UpdateFile
+	return unmarshal((*plain)(u))
+}
+
+func validateUpdateFiles(files []UpdateFile) SettingsErrors {
+	errs := SettingsErrors{}
+
+	for index, file := range files {
+		if file.Path == "" {
+			errs = append(errs, &SettingsError{Key: "update-files", Message: fmt.Sprintf("entry %d must have a path", index)})
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,190 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+)
+
+// Severity is how serious a finding is
+type Severity string
+
+const (
+	// SeverityWarning findings point out likely mistakes
+	SeverityWarning Severity = "warning"
+	// SeverityError findings break a rule that was asked for
+	SeverityError Severity = "error"
+)
+
+// Finding is a problem found in a commit message
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+// MessageOptions controls which rules are applied to a commit message
+type MessageOptions struct {
+	// RequireBump reports messages that do not match any bump pattern
+	RequireBump bool
+}
+
+// MessageResult is the outcome of linting a commit message
+type MessageResult struct {
+	Bump     git.Bump
+	Findings []*Finding
+}
+
+var (
+	semverKeyword      = regexp.MustCompile(`\+semver:\s*([A-Za-z]+)`)
+	conventionalHeader = regexp.MustCompile(`^([A-Za-z]+)(\([^)]*\))?!?:\s`)
+	scissors           = "# ------------------------ >8 ------------------------"
+)
+
+var semverKeywords = []string{"breaking", "major", "feature", "minor", "fix", "patch", "none", "skip"}
+
+var conventionalTypes = []string{"feat", "fix", "build", "chore", "ci", "docs", "style", "refactor", "perf", "test", "revert"}
+
+// CleanMessage removes the comment lines and anything below the scissors line that git adds to
+// the message file given to the commit-msg hook
+func CleanMessage(message string) string {
+	lines := []string{}
+	for _, line := range strings.Split(message, "\n") {
+		if line == scissors {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// LintMessage checks a commit message against the bump patterns in the settings. It warns about
+// messages that ask for more than one bump and about +semver keywords and conventional commit
+// types that are close to, but not, a recognised keyword.
+func LintMessage(settings *git.Settings, message string, options *MessageOptions) (*MessageResult, error) {
+	bumps, err := settings.GetMessageBumps(message)
+	if err != nil {
+		return nil, err
+	}
+
+	bump := git.BumpDefault
+	if len(bumps) > 0 {
+		bump = bumps[0]
+	}
+
+	result := &MessageResult{Bump: bump, Findings: []*Finding{}}
+
+	if len(bumps) > 1 {
+		names := []string{}
+		for _, b := range bumps {
+			names = append(names, b.String())
+		}
+		result.Findings = append(result.Findings, &Finding{
+			Severity: SeverityWarning,
+			Rule:     "conflicting-keywords",
+			Message:  fmt.Sprintf("the message asks for more than one bump (%s), the %s bump wins", strings.Join(names, ", "), bump),
+		})
+	}
+
+	for _, match := range semverKeyword.FindAllStringSubmatch(message, -1) {
+		bumps, err := settings.GetMessageBumps(match[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(bumps) > 0 {
+			continue
+		}
+
+		result.Findings = append(result.Findings, &Finding{
+			Severity: SeverityWarning,
+			Rule:     "unknown-keyword",
+			Message:  fmt.Sprintf("'%s' is not a recognised keyword%s", match[0], suggest(match[1], semverKeywords)),
+		})
+	}
+
+	header := strings.SplitN(message, "\n", 2)[0]
+	if match := conventionalHeader.FindStringSubmatch(header); match != nil && !contains(conventionalTypes, strings.ToLower(match[1])) {
+		suggestion := suggest(match[1], conventionalTypes)
+		if suggestion != "" {
+			result.Findings = append(result.Findings, &Finding{
+				Severity: SeverityWarning,
+				Rule:     "unknown-type",
+				Message:  fmt.Sprintf("'%s' is not a conventional commit type%s", match[1], suggestion),
+			})
+		}
+	}
+
+	if options.RequireBump && bump == git.BumpDefault {
+		result.Findings = append(result.Findings, &Finding{
+			Severity: SeverityError,
+			Rule:     "missing-bump",
+			Message:  "the message does not contain a version bump keyword",
+		})
+	}
+
+	return result, nil
+}
+
+// suggest returns a hint naming the keyword closest to word, or an empty string if none is close.
+func suggest(word string, keywords []string) string {
+	best := ""
+	bestDistance := 3 // only suggest keywords within two edits
+	for _, keyword := range keywords {
+		distance := levenshtein(strings.ToLower(word), keyword)
+		if distance < bestDistance {
+			best = keyword
+			bestDistance = distance
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean '%s'?", best)
+}
+
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package lint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+	"github.com/syncromatics/gogitver/pkg/lint"
+)
+
+func TestLintMessageWarnsOnMisspelledKeyword(t *testing.T) {
+	result, err := lint.LintMessage(git.GetDefaultSettings(), "(+semver: feture) add a feature", &lint.MessageOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, git.BumpDefault, result.Bump)
+	assert.Equal(t, []*lint.Finding{
+		{
+			Severity: lint.SeverityWarning,
+			Rule:     "unknown-keyword",
+			Message:  "'+semver: feture' is not a recognised keyword, did you mean 'feature'?",
+		},
+	}, result.Findings)
+}
+
+func TestLintMessageWarnsOnMisspelledConventionalType(t *testing.T) {
+	settings, err := git.GetSettingsFromFile(strings.NewReader(`
+minor-version-bump-message: '^feat(\(.*\))?:'
+patch-version-bump-message: '^fix(\(.*\))?:'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := lint.LintMessage(settings, "fxi(parser): handle empty input", &lint.MessageOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*lint.Finding{
+		{
+			Severity: lint.SeverityWarning,
+			Rule:     "unknown-type",
+			Message:  "'fxi' is not a conventional commit type, did you mean 'fix'?",
+		},
+	}, result.Findings)
+}
+
+func TestLintMessageAcceptsRecognisedKeyword(t *testing.T) {
+	result, err := lint.LintMessage(git.GetDefaultSettings(), "(+semver: feature) add a feature", &lint.MessageOptions{RequireBump: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, git.BumpMinor, result.Bump)
+	assert.Empty(t, result.Findings)
+}
+
+func TestLintMessageRequiresBump(t *testing.T) {
+	result, err := lint.LintMessage(git.GetDefaultSettings(), "add a feature", &lint.MessageOptions{RequireBump: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*lint.Finding{
+		{
+			Severity: lint.SeverityError,
+			Rule:     "missing-bump",
+			Message:  "the message does not contain a version bump keyword",
+		},
+	}, result.Findings)
+}
+
+func TestCleanMessageRemovesGitComments(t *testing.T) {
+	message := `(+semver: fix) a fix
+
+body
+# Please enter the commit message for your changes.
+# ------------------------ >8 ------------------------
+diff --git a/file b/file
+`
+
+	assert.Equal(t, "(+semver: fix) a fix\n\nbody", lint.CleanMessage(message))
+}
+
+func TestLintMessageWarnsOnConflictingKeywords(t *testing.T) {
+	result, err := lint.LintMessage(git.GetDefaultSettings(), "(+semver: major) rework the parser\n\n(+semver: fix) handle empty input", &lint.MessageOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, git.BumpMajor, result.Bump)
+	assert.Equal(t, []*lint.Finding{
+		{
+			Severity: lint.SeverityWarning,
+			Rule:     "conflicting-keywords",
+			Message:  "the message asks for more than one bump (major, patch), the major bump wins",
+		},
+	}, result.Findings)
+}
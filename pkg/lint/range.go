@@ -0,0 +1,84 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+	gogit "gopkg.in/src-d/go-git.v4"
+)
+
+// RangeOptions controls which rules are applied to the commits in a range
+type RangeOptions struct {
+	// RequireBump makes commits without a bump keyword an error instead of a warning
+	RequireBump bool
+}
+
+// CommitReport is the outcome of linting one commit in a range
+type CommitReport struct {
+	Commit   string     `json:"commit"`
+	Subject  string     `json:"subject"`
+	Bump     string     `json:"bump"`
+	Findings []*Finding `json:"findings"`
+}
+
+// RangeReport is the outcome of linting every commit in a range
+type RangeReport struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Bump     string          `json:"bump"`
+	Errors   int             `json:"errors"`
+	Warnings int             `json:"warnings"`
+	Commits  []*CommitReport `json:"commits"`
+}
+
+// LintRange lints the message of every commit reachable from to but not from from, and reports
+// the bump merging them into master would make. Ignored commits are left out of the report and
+// merge commits are not required to carry a bump keyword.
+func LintRange(r *gogit.Repository, settings *git.Settings, from string, to string, options *RangeOptions) (*RangeReport, error) {
+	commitRange, err := git.GetCommitRange(r, from, to, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RangeReport{
+		From:    from,
+		To:      to,
+		Bump:    commitRange.Bump.String(),
+		Commits: []*CommitReport{},
+	}
+
+	for _, commit := range commitRange.Commits {
+		if commit.Ignored {
+			continue
+		}
+
+		result, err := LintMessage(settings, commit.Message, &MessageOptions{
+			RequireBump: !commit.Merge,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, finding := range result.Findings {
+			if finding.Rule == "missing-bump" && !options.RequireBump {
+				finding.Severity = SeverityWarning
+			}
+
+			switch finding.Severity {
+			case SeverityError:
+				report.Errors++
+			case SeverityWarning:
+				report.Warnings++
+			}
+		}
+
+		report.Commits = append(report.Commits, &CommitReport{
+			Commit:   commit.Hash,
+			Subject:  strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+			Bump:     result.Bump.String(),
+			Findings: result.Findings,
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+)
+
+const maxDockerTagLength = 128
+
+var invalidDockerTagCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// DockerTags returns the docker tags for a version. Mainline versions, which have no prerelease,
+// are tagged with the full version and, depending on the settings, with floating major.minor and
+// major tags and latest. Prerelease versions, versions with build metadata and versions built from
+// a dirty working tree are only tagged with the full version and, if enabled, their branch name,
+// so they never replace the tags of a release. Every tag is sanitised to the characters docker allows.
+func DockerTags(v *Variables, settings *git.DockerTagSettings) []string {
+	tags := []string{sanitiseDockerTag(settings.Prefix + v.SemVer)}
+
+	if v.PreRelease != "" || v.Metadata != "" || v.Dirty {
+		if settings.Branch && v.Branch != "" {
+			tags = append(tags, sanitiseDockerTag(v.Branch))
+		}
+		return tags
+	}
+
+	if settings.Floating {
+		tags = append(tags,
+			sanitiseDockerTag(fmt.Sprintf("%s%d.%d", settings.Prefix, v.Major, v.Minor)),
+			sanitiseDockerTag(fmt.Sprintf("%s%d", settings.Prefix, v.Major)))
+	}
+
+	if settings.Latest {
+		tags = append(tags, "latest")
+	}
+
+	return tags
+}
+
+// sanitiseDockerTag replaces the characters docker does not allow in a tag, such as the + of build
+// metadata, and makes sure the tag neither starts with a period or dash nor is too long.
+func sanitiseDockerTag(tag string) string {
+	tag = invalidDockerTagCharacters.ReplaceAllString(tag, "-")
+	if strings.HasPrefix(tag, ".") || strings.HasPrefix(tag, "-") {
+		tag = "_" + tag[1:]
+	}
+	if len(tag) > maxDockerTagLength {
+		tag = tag[:maxDockerTagLength]
+	}
+	return tag
+}
+
+func writeDockerTags(w io.Writer, v *Variables, options *Options) error {
+	settings := options.DockerTags
+	if settings == nil {
+		settings = &git.GetDefaultSettings().DockerTags
+	}
+
+	for _, tag := range DockerTags(v, settings) {
+		_, err := fmt.Fprintln(w, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
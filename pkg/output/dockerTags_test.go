@@ -0,0 +1,71 @@
+package output_test
+
+import (
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+	"github.com/syncromatics/gogitver/pkg/output"
+)
+
+func TestDockerTagsForMainlineVersion(t *testing.T) {
+	v := &output.Variables{SemVer: "1.2.3", Major: 1, Minor: 2, Patch: 3, Branch: "master"}
+
+	tags := output.DockerTags(v, &git.GetDefaultSettings().DockerTags)
+
+	assert.Equal(t, []string{"1.2.3", "1.2", "1", "latest"}, tags)
+}
+
+func TestDockerTagsForPrereleaseVersion(t *testing.T) {
+	v := testVariables()
+
+	tags := output.DockerTags(v, &git.DockerTagSettings{Latest: true, Floating: true, Branch: true})
+
+	assert.Equal(t, []string{"1.3.0-feature-x-4-ab12", "feature-x"}, tags)
+}
+
+func TestDockerTagsWithPrefixAndWithoutFloatingTags(t *testing.T) {
+	v := &output.Variables{SemVer: "1.2.3", Major: 1, Minor: 2, Patch: 3}
+
+	tags := output.DockerTags(v, &git.DockerTagSettings{Prefix: "v"})
+
+	assert.Equal(t, []string{"v1.2.3"}, tags)
+}
+
+func TestDockerTagsAreSanitised(t *testing.T) {
+	v := &output.Variables{SemVer: "1.2.3+build.5", Major: 1, Minor: 2, Patch: 3, Metadata: "build.5"}
+
+	tags := output.DockerTags(v, &git.DockerTagSettings{})
+
+	assert.Equal(t, []string{"1.2.3-build.5"}, tags)
+}
+
+func TestDockerTagsForDirtyMainlineVersion(t *testing.T) {
+	v := output.NewVariables(&git.VersionInfo{
+		Version: semver.New("0.1.0+dirty"),
+		Branch:  "master",
+		Dirty:   true,
+	})
+
+	tags := output.DockerTags(v, &git.GetDefaultSettings().DockerTags)
+
+	assert.Equal(t, []string{"0.1.0-dirty"}, tags)
+}
+
+func TestDockerTagsForDirtyVersionWithoutMarker(t *testing.T) {
+	v := &output.Variables{SemVer: "0.1.0", Major: 0, Minor: 1, Patch: 0, Branch: "master", Dirty: true}
+
+	tags := output.DockerTags(v, &git.GetDefaultSettings().DockerTags)
+
+	assert.Equal(t, []string{"0.1.0"}, tags)
+}
+
+func TestDockerTagsForMainlineVersionWithMetadata(t *testing.T) {
+	v := &output.Variables{SemVer: "1.2.3+build.5", Major: 1, Minor: 2, Patch: 3, Metadata: "build.5"}
+
+	tags := output.DockerTags(v, &git.GetDefaultSettings().DockerTags)
+
+	assert.Equal(t, []string{"1.2.3-build.5"}, tags)
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/gogitver/pkg/git"
+)
+
+// Options are the settings shared by the output formats
+type Options struct {
+	// Prefix is prepended to variable names
+	Prefix string
+	// DockerTags selects the tags listed by the docker-tags format, the default settings are used when it is nil
+	DockerTags *git.DockerTagSettings
+	// LdflagsVars are the Go variables set by the ldflags format, see Ldflags
+	LdflagsVars []string
+}
+
+// Format writes the variables to w
+type Format func(w io.Writer, v *Variables, options *Options) error
+
+var formats = map[string]Format{
+	"version":     writeVersion,
+	"json":        writeJSON,
+	"env":         writeEnv,
+	"dotenv":      writeDotenv,
+	"make":        writeMake,
+	"powershell":  writePowershell,
+	"docker-tags": writeDockerTags,
+	"ldflags":     writeLdflags,
+}
+
+var (
+	safeShellValue = regexp.MustCompile(`^[A-Za-z0-9_.,:/@+=-]*$`)
+	validName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// GetFormat returns the output format with the given name
+func GetFormat(name string) (Format, error) {
+	format, ok := formats[name]
+	if !ok {
+		return nil, errors.Errorf("unknown output format '%s', expected one of %s", name, strings.Join(FormatNames(), ", "))
+	}
+	return format, nil
+}
+
+// FormatNames returns the names of the output formats in alphabetical order
+func FormatNames() []string {
+	names := []string{}
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeVersion(w io.Writer, v *Variables, options *Options) error {
+	_, err := fmt.Fprintln(w, v.SemVer)
+	return err
+}
+
+func writeJSON(w io.Writer, v *Variables, options *Options) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeEnv writes shell assignments that can be evaluated by sh, bash and zsh
+func writeEnv(w io.Writer, v *Variables, options *Options) error {
+	return writeVariables(w, v, options, func(variable Variable) string {
+		return fmt.Sprintf("%s=%s", variable.Name, shellQuote(variable.Value))
+	})
+}
+
+// writeDotenv writes a .env file as read by docker compose and the dotenv libraries
+func writeDotenv(w io.Writer, v *Variables, options *Options) error {
+	return writeVariables(w, v, options, func(variable Variable) string {
+		if safeShellValue.MatchString(variable.Value) {
+			return fmt.Sprintf("%s=%s", variable.Name, variable.Value)
+		}
+		return fmt.Sprintf("%s=%s", variable.Name, strconv.Quote(variable.Value))
+	})
+}
+
+// writeMake writes simply expanded variables that can be included in a Makefile
+func writeMake(w io.Writer, v *Variables, options *Options) error {
+	return writeVariables(w, v, options, func(variable Variable) string {
+		return strings.TrimSpace(fmt.Sprintf("%s := %s", variable.Name, strings.Replace(variable.Value, "$", "$$", -1)))
+	})
+}
+
+// writePowershell writes environment variable assignments that can be dot sourced or passed to Invoke-Expression
+func writePowershell(w io.Writer, v *Variables, options *Options) error {
+	return writeVariables(w, v, options, func(variable Variable) string {
+		return fmt.Sprintf("$env:%s = '%s'", variable.Name, strings.Replace(variable.Value, "'", "''", -1))
+	})
+}
+
+func writeVariables(w io.Writer, v *Variables, options *Options, line func(Variable) string) error {
+	for _, variable := range v.List(options.Prefix) {
+		if !validName.MatchString(variable.Name) {
+			return errors.Errorf("'%s' is not a valid variable name", variable.Name)
+		}
+
+		_, err := fmt.Fprintln(w, line(variable))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shellQuote(value string) string {
+	if safeShellValue.MatchString(value) {
+		return value
+	}
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func formatInt(value int64) string {
+	return strconv.FormatInt(value, 10)
+}
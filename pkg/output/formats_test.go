@@ -0,0 +1,134 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+	"github.com/syncromatics/gogitver/pkg/output"
+)
+
+func testVariables() *output.Variables {
+	return output.NewVariables(&git.VersionInfo{
+		Version:    semver.New("1.3.0-feature-x-4-ab12"),
+		Commit:     "ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12",
+		CommitDate: time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC),
+		Branch:     "feature-x",
+	})
+}
+
+func write(t *testing.T, name string, v *output.Variables, options *output.Options) string {
+	format, err := output.GetFormat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := &bytes.Buffer{}
+	err = format(buffer, v, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer.String()
+}
+
+func TestNewVariables(t *testing.T) {
+	v := testVariables()
+
+	assert.Equal(t, &output.Variables{
+		SemVer:      "1.3.0-feature-x-4-ab12",
+		Major:       1,
+		Minor:       3,
+		Patch:       0,
+		PreRelease:  "feature-x-4-ab12",
+		Branch:      "feature-x",
+		Commit:      "ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12",
+		ShortCommit: "ab12cd3",
+		CommitDate:  "2019-03-04T05:06:07Z",
+	}, v)
+}
+
+func TestEnvFormatQuotesValues(t *testing.T) {
+	v := testVariables()
+	v.Branch = "it's"
+
+	result := write(t, "env", v, &output.Options{Prefix: output.DefaultPrefix})
+
+	assert.Contains(t, result, "GOGITVER_MAJOR=1\n")
+	assert.Contains(t, result, "GOGITVER_SEMVER=1.3.0-feature-x-4-ab12\n")
+	assert.Contains(t, result, `GOGITVER_BRANCH='it'\''s'`+"\n")
+}
+
+func TestDotenvFormat(t *testing.T) {
+	v := testVariables()
+	v.Branch = "two words"
+
+	result := write(t, "dotenv", v, &output.Options{Prefix: "APP_"})
+
+	assert.Contains(t, result, "APP_PATCH=0\n")
+	assert.Contains(t, result, `APP_BRANCH="two words"`+"\n")
+}
+
+func TestMakeFormatEscapesDollars(t *testing.T) {
+	v := testVariables()
+	v.Branch = "a$b"
+
+	result := write(t, "make", v, &output.Options{Prefix: output.DefaultPrefix})
+
+	assert.Contains(t, result, "GOGITVER_MINOR := 3\n")
+	assert.Contains(t, result, "GOGITVER_BRANCH := a$$b\n")
+	assert.Contains(t, result, "GOGITVER_METADATA :=\n")
+}
+
+func TestPowershellFormat(t *testing.T) {
+	v := testVariables()
+	v.Branch = "it's"
+
+	result := write(t, "powershell", v, &output.Options{Prefix: output.DefaultPrefix})
+
+	assert.Contains(t, result, "$env:GOGITVER_SEMVER = '1.3.0-feature-x-4-ab12'\n")
+	assert.Contains(t, result, "$env:GOGITVER_BRANCH = 'it''s'\n")
+}
+
+func TestFormatsRejectInvalidPrefix(t *testing.T) {
+	format, err := output.GetFormat("env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = format(&bytes.Buffer{}, testVariables(), &output.Options{Prefix: "MY-"})
+
+	assert.EqualError(t, err, "'MY-SEMVER' is not a valid variable name")
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := output.GetFormat("yaml")
+
+	assert.EqualError(t, err, "unknown output format 'yaml', expected one of docker-tags, dotenv, env, json, ldflags, make, powershell, version")
+}
+
+func TestPullRequestVariables(t *testing.T) {
+	v := output.NewVariables(&git.VersionInfo{
+		Version:       semver.New("1.3.0-pr.123.4"),
+		PullRequest:   &git.PullRequest{Number: 123, TargetBranch: "master"},
+		MergedVersion: semver.New("1.3.0"),
+	})
+
+	result := write(t, "env", v, &output.Options{Prefix: output.DefaultPrefix})
+
+	assert.Equal(t, 123, v.PullRequest)
+	assert.Contains(t, result, "GOGITVER_SEMVER=1.3.0-pr.123.4\n")
+	assert.Contains(t, result, "GOGITVER_PULL_REQUEST=123\n")
+	assert.Contains(t, result, "GOGITVER_MERGED_SEMVER=1.3.0\n")
+}
+
+func TestDirtyVariable(t *testing.T) {
+	dirty := output.NewVariables(&git.VersionInfo{Version: semver.New("1.3.0"), Dirty: true})
+	clean := output.NewVariables(&git.VersionInfo{Version: semver.New("1.3.0")})
+
+	assert.Contains(t, write(t, "env", dirty, &output.Options{Prefix: output.DefaultPrefix}), "GOGITVER_DIRTY=true\n")
+	assert.Contains(t, write(t, "env", clean, &output.Options{Prefix: output.DefaultPrefix}), "GOGITVER_DIRTY=false\n")
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strconv"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(`// Code generated by gogitver. DO NOT EDIT.
+
+package {{.Package}}
+
+// The version of this build as calculated by gogitver
+const (
+	// SemVer is the full semantic version
+	SemVer = {{quote .SemVer}}
+	// Major is the major version
+	Major = {{.Major}}
+	// Minor is the minor version
+	Minor = {{.Minor}}
+	// Patch is the patch version
+	Patch = {{.Patch}}
+	// PreRelease is the prerelease label, empty for versions on master
+	PreRelease = {{quote .PreRelease}}
+	// Commit is the hash of the commit that was versioned
+	Commit = {{quote .Commit}}
+	// Branch is the branch that was versioned
+	Branch = {{quote .Branch}}
+	// CommitDate is the committer date of the commit in RFC 3339 format
+	CommitDate = {{quote .CommitDate}}
+)
+`))
+
+var csharpTemplate = template.Must(template.New("csharp").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(`//------------------------------------------------------------------------------
+// <auto-generated>
+//     This code was generated by gogitver.
+//     Changes to this file will be lost when the code is regenerated.
+// </auto-generated>
+//------------------------------------------------------------------------------
+
+using System.Reflection;
+
+[assembly: AssemblyVersion("{{.Major}}.{{.Minor}}.{{.Patch}}.0")]
+[assembly: AssemblyFileVersion("{{.Major}}.{{.Minor}}.{{.Patch}}.0")]
+[assembly: AssemblyInformationalVersion({{quote .SemVer}})]
+
+{{if .Namespace}}namespace {{.Namespace}}
+{
+{{end}}{{.Indent}}internal static class GitVersionInformation
+{{.Indent}}{
+{{.Indent}}    public const string SemVer = {{quote .SemVer}};
+{{.Indent}}    public const int Major = {{.Major}};
+{{.Indent}}    public const int Minor = {{.Minor}};
+{{.Indent}}    public const int Patch = {{.Patch}};
+{{.Indent}}    public const string PreRelease = {{quote .PreRelease}};
+{{.Indent}}    public const string Commit = {{quote .Commit}};
+{{.Indent}}    public const string Branch = {{quote .Branch}};
+{{.Indent}}    public const string CommitDate = {{quote .CommitDate}};
+{{.Indent}}}
+{{if .Namespace}}}
+{{end}}`))
+
+// GenerateGo returns a Go source file that declares the variables as constants in the given package
+func GenerateGo(v *Variables, packageName string) ([]byte, error) {
+	if !validName.MatchString(packageName) {
+		return nil, errors.Errorf("'%s' is not a valid package name", packageName)
+	}
+
+	buffer := &bytes.Buffer{}
+	err := goTemplate.Execute(buffer, struct {
+		*Variables
+		Package string
+	}{v, packageName})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buffer.Bytes())
+}
+
+// GenerateCSharp returns a C# source file with the assembly version attributes and a
+// GitVersionInformation class holding the variables, in the given namespace unless it is empty
+func GenerateCSharp(v *Variables, namespace string) ([]byte, error) {
+	indent := ""
+	if namespace != "" {
+		indent = "    "
+	}
+
+	buffer := &bytes.Buffer{}
+	err := csharpTemplate.Execute(buffer, struct {
+		*Variables
+		Namespace string
+		Indent    string
+	}{v, namespace, indent})
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateJSON returns the variables as a JSON document
+func GenerateJSON(v *Variables) ([]byte, error) {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("%s\n", content)), nil
+}
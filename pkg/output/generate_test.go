@@ -0,0 +1,60 @@
+package output_test
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/syncromatics/gogitver/pkg/output"
+)
+
+func TestGenerateGo(t *testing.T) {
+	content, err := output.GenerateGo(testVariables(), "version")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "version_gen.go", content, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "version", file.Name.Name)
+	assert.Contains(t, string(content), "// Code generated by gogitver. DO NOT EDIT.\n")
+	assert.Contains(t, string(content), "\tSemVer = \"1.3.0-feature-x-4-ab12\"\n")
+	assert.Contains(t, string(content), "\tMinor = 3\n")
+	assert.Contains(t, string(content), "\tCommitDate = \"2019-03-04T05:06:07Z\"\n")
+}
+
+func TestGenerateGoRejectsInvalidPackage(t *testing.T) {
+	_, err := output.GenerateGo(testVariables(), "my-version")
+
+	assert.EqualError(t, err, "'my-version' is not a valid package name")
+}
+
+func TestGenerateCSharp(t *testing.T) {
+	content, err := output.GenerateCSharp(testVariables(), "My.App")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, string(content), "[assembly: AssemblyVersion(\"1.3.0.0\")]\n")
+	assert.Contains(t, string(content), "[assembly: AssemblyInformationalVersion(\"1.3.0-feature-x-4-ab12\")]\n")
+	assert.Contains(t, string(content), "namespace My.App\n{\n    internal static class GitVersionInformation\n")
+	assert.Contains(t, string(content), "        public const string Branch = \"feature-x\";\n")
+}
+
+func TestGenerateJSON(t *testing.T) {
+	content, err := output.GenerateJSON(testVariables())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &output.Variables{}
+	err = json.Unmarshal(content, v)
+	assert.Nil(t, err)
+	assert.Equal(t, testVariables(), v)
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultLdflagsVar is set to the version when no ldflags variables are given
+const DefaultLdflagsVar = "main.version"
+
+var (
+	goSymbol         = regexp.MustCompile(`^[^\s=]+\.[A-Za-z_][A-Za-z0-9_]*$`)
+	variableAliases  = map[string]string{"version": "semver", "date": "commit_date"}
+	safeLdflagsValue = regexp.MustCompile(`^[^\s'"\\]*$`)
+)
+
+// Lookup returns the value of a variable by its name in List, ignoring case and the prefix. The
+// aliases version and date name SEMVER and COMMIT_DATE.
+func (v *Variables) Lookup(name string) (string, bool) {
+	name = strings.ToLower(strings.Replace(name, "-", "_", -1))
+	if alias, ok := variableAliases[name]; ok {
+		name = alias
+	}
+
+	for _, variable := range v.List("") {
+		if strings.ToLower(variable.Name) == name {
+			return variable.Value, true
+		}
+	}
+	return "", false
+}
+
+// Ldflags returns the go build -ldflags arguments that set each of the Go string variables in vars.
+// Each entry is a fully qualified variable such as main.version, optionally followed by = and the
+// name of the version variable it is set to, e.g. main.commit=commit. Without a name the variable
+// is set to the version.
+func Ldflags(v *Variables, vars []string) (string, error) {
+	if len(vars) == 0 {
+		vars = []string{DefaultLdflagsVar}
+	}
+
+	flags := []string{}
+	for _, entry := range vars {
+		parts := strings.SplitN(entry, "=", 2)
+		symbol, name := parts[0], "version"
+		if len(parts) == 2 {
+			name = parts[1]
+		}
+
+		if !goSymbol.MatchString(symbol) {
+			return "", errors.Errorf("'%s' is not a fully qualified Go variable such as main.version", symbol)
+		}
+
+		value, ok := v.Lookup(name)
+		if !ok {
+			return "", errors.Errorf("unknown variable '%s' for %s", name, symbol)
+		}
+
+		flag := fmt.Sprintf("%s=%s", symbol, value)
+		if !safeLdflagsValue.MatchString(value) {
+			flag = "'" + flag + "'"
+		}
+		flags = append(flags, "-X "+flag)
+	}
+
+	return strings.Join(flags, " "), nil
+}
+
+func writeLdflags(w io.Writer, v *Variables, options *Options) error {
+	flags, err := Ldflags(v, options.LdflagsVars)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, flags)
+	return err
+}
@@ -0,0 +1,49 @@
+package output_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/syncromatics/gogitver/pkg/output"
+)
+
+func TestLdflagsDefaultsToMainVersion(t *testing.T) {
+	flags, err := output.Ldflags(testVariables(), nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "-X main.version=1.3.0-feature-x-4-ab12", flags)
+}
+
+func TestLdflagsSetsSeveralVariables(t *testing.T) {
+	flags, err := output.Ldflags(testVariables(), []string{
+		"main.version",
+		"github.com/example/app/version.Commit=commit",
+		"main.date=date",
+		"main.branch=BRANCH",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "-X main.version=1.3.0-feature-x-4-ab12 "+
+		"-X github.com/example/app/version.Commit=ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12 "+
+		"-X main.date=2019-03-04T05:06:07Z "+
+		"-X main.branch=feature-x", flags)
+}
+
+func TestLdflagsQuotesValuesWithSpaces(t *testing.T) {
+	v := testVariables()
+	v.Branch = "two words"
+
+	flags, err := output.Ldflags(v, []string{"main.branch=branch"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "-X 'main.branch=two words'", flags)
+}
+
+func TestLdflagsRejectsInvalidEntries(t *testing.T) {
+	_, err := output.Ldflags(testVariables(), []string{"version"})
+	assert.EqualError(t, err, "'version' is not a fully qualified Go variable such as main.version")
+
+	_, err = output.Ldflags(testVariables(), []string{"main.version=build"})
+	assert.EqualError(t, err, "unknown variable 'build' for main.version")
+}
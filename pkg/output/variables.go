@@ -0,0 +1,93 @@
+package output
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+)
+
+// DefaultPrefix is prepended to the variable names of the shell, make and powershell formats
+const DefaultPrefix = "GOGITVER_"
+
+// Variables is the version information every output format is built from
+type Variables struct {
+	SemVer      string `json:"semVer"`
+	Major       int64  `json:"major"`
+	Minor       int64  `json:"minor"`
+	Patch       int64  `json:"patch"`
+	PreRelease  string `json:"preRelease"`
+	Metadata    string `json:"metadata"`
+	Branch      string `json:"branch"`
+	Commit      string `json:"commit"`
+	ShortCommit string `json:"shortCommit"`
+	CommitDate  string `json:"commitDate"`
+
+	// PullRequest is the number of the pull request that was built, 0 for other builds
+	PullRequest int `json:"pullRequest"`
+	// MergedSemVer is the version the target branch would have after merging the pull request
+	MergedSemVer string `json:"mergedSemVer"`
+
+	// Dirty is set when the version was calculated from a working tree with uncommitted changes
+	Dirty bool `json:"dirty"`
+}
+
+// Variable is a single named value of Variables
+type Variable struct {
+	Name  string
+	Value string
+}
+
+// NewVariables creates the variables for a calculated version
+func NewVariables(info *git.VersionInfo) *Variables {
+	v := &Variables{
+		SemVer:     info.Version.String(),
+		Major:      info.Version.Major,
+		Minor:      info.Version.Minor,
+		Patch:      info.Version.Patch,
+		PreRelease: string(info.Version.PreRelease),
+		Metadata:   info.Version.Metadata,
+		Branch:     info.Branch,
+		Commit:     info.Commit,
+		Dirty:      info.Dirty,
+	}
+
+	if len(info.Commit) >= 7 {
+		v.ShortCommit = info.Commit[:7]
+	}
+	if !info.CommitDate.IsZero() {
+		v.CommitDate = info.CommitDate.Format(time.RFC3339)
+	}
+	if info.PullRequest != nil {
+		v.PullRequest = info.PullRequest.Number
+	}
+	if info.MergedVersion != nil {
+		v.MergedSemVer = info.MergedVersion.String()
+	}
+
+	return v
+}
+
+// List returns the variables in a fixed order with upper case names, each starting with prefix
+func (v *Variables) List(prefix string) []Variable {
+	pullRequest := ""
+	if v.PullRequest > 0 {
+		pullRequest = formatInt(int64(v.PullRequest))
+	}
+
+	return []Variable{
+		{prefix + "SEMVER", v.SemVer},
+		{prefix + "MAJOR", formatInt(v.Major)},
+		{prefix + "MINOR", formatInt(v.Minor)},
+		{prefix + "PATCH", formatInt(v.Patch)},
+		{prefix + "PRERELEASE", v.PreRelease},
+		{prefix + "METADATA", v.Metadata},
+		{prefix + "BRANCH", v.Branch},
+		{prefix + "COMMIT", v.Commit},
+		{prefix + "SHORT_COMMIT", v.ShortCommit},
+		{prefix + "COMMIT_DATE", v.CommitDate},
+		{prefix + "PULL_REQUEST", pullRequest},
+		{prefix + "MERGED_SEMVER", v.MergedSemVer},
+		{prefix + "DIRTY", strconv.FormatBool(v.Dirty)},
+	}
+}
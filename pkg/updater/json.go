@@ -0,0 +1,82 @@
+package updater
+
+import (
+	"github.com/pkg/errors"
+)
+
+// updatePackageJSON sets the top level version of an npm package.json
+func updatePackageJSON(content []byte, version string) ([]byte, error) {
+	start, end, err := findJSONString(content, "version")
+	if err != nil {
+		return nil, err
+	}
+
+	return replace(content, start, end, version), nil
+}
+
+// findJSONString returns the offsets of the contents of the string value of a key in the top
+// level object of a JSON document.
+func findJSONString(content []byte, key string) (int, int, error) {
+	depth := 0
+	expectKey := false
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '{':
+			depth++
+			expectKey = depth == 1
+		case '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			expectKey = depth == 1
+		case '"':
+			end := findJSONStringEnd(content, i)
+			if end < 0 {
+				return 0, 0, errors.New("unterminated string")
+			}
+
+			if depth == 1 && expectKey {
+				expectKey = false
+				if string(content[i+1:end]) == key {
+					value := skipJSONSpace(content, end+1)
+					if value < len(content) && content[value] == ':' {
+						value = skipJSONSpace(content, value+1)
+					}
+					if value >= len(content) || content[value] != '"' {
+						return 0, 0, errors.Errorf("the \"%s\" field is not a string", key)
+					}
+
+					valueEnd := findJSONStringEnd(content, value)
+					if valueEnd < 0 {
+						return 0, 0, errors.New("unterminated string")
+					}
+					return value + 1, valueEnd, nil
+				}
+			}
+			i = end
+		}
+	}
+
+	return 0, 0, errors.Errorf("no top level \"%s\" field", key)
+}
+
+// findJSONStringEnd returns the offset of the quote that closes the string starting at start, or -1
+func findJSONStringEnd(content []byte, start int) int {
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func skipJSONSpace(content []byte, i int) int {
+	for i < len(content) && (content[i] == ' ' || content[i] == '\t' || content[i] == '\n' || content[i] == '\r') {
+		i++
+	}
+	return i
+}
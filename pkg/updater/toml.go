@@ -0,0 +1,55 @@
+package updater
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	tomlTable   = regexp.MustCompile(`^\s*\[\s*([^\]\s]+)\s*\]`)
+	tomlVersion = regexp.MustCompile(`^\s*version\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// updateCargo sets the package version of a Cargo.toml
+func updateCargo(content []byte, version string) ([]byte, error) {
+	return updateTOMLVersion(content, []string{"package"}, version)
+}
+
+// updatePyproject sets the project version of a pyproject.toml, either the standard one or poetry's
+func updatePyproject(content []byte, version string) ([]byte, error) {
+	return updateTOMLVersion(content, []string{"project", "tool.poetry"}, version)
+}
+
+// updateTOMLVersion replaces the string value of the version key in the first of the tables that has one
+func updateTOMLVersion(content []byte, tables []string, version string) ([]byte, error) {
+	table := ""
+	offset := 0
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if match := tomlTable.FindSubmatch(line); match != nil {
+			table = string(match[1])
+		} else if contains(tables, table) {
+			if match := tomlVersion.FindSubmatchIndex(line); match != nil {
+				for group := 1; group <= 2; group++ {
+					if match[group*2] >= 0 {
+						return replace(content, offset+match[group*2], offset+match[group*2+1], version), nil
+					}
+				}
+			}
+		}
+		offset += len(line)
+	}
+
+	return nil, errors.Errorf("no version key in [%s]", strings.Join(tables, "] or ["))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
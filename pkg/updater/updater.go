@@ -0,0 +1,147 @@
+package updater
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/gogitver/pkg/git"
+)
+
+// Updater sets the version in the content of one type of file, leaving the rest of it untouched
+type Updater interface {
+	Update(content []byte, version string) ([]byte, error)
+}
+
+// UpdaterFunc is a function that implements Updater
+type UpdaterFunc func(content []byte, version string) ([]byte, error)
+
+// Update calls f
+func (f UpdaterFunc) Update(content []byte, version string) ([]byte, error) {
+	return f(content, version)
+}
+
+type registration struct {
+	patterns []string
+	updater  Updater
+}
+
+var updaters = map[string]*registration{
+	"npm":       {[]string{"package.json"}, UpdaterFunc(updatePackageJSON)},
+	"helm":      {[]string{"Chart.yaml"}, UpdaterFunc(updateChart)},
+	"msbuild":   {[]string{"*.csproj", "*.fsproj", "*.vbproj", "*.props"}, UpdaterFunc(updateMSBuild)},
+	"maven":     {[]string{"pom.xml"}, UpdaterFunc(updatePom)},
+	"cargo":     {[]string{"Cargo.toml"}, UpdaterFunc(updateCargo)},
+	"pyproject": {[]string{"pyproject.toml"}, UpdaterFunc(updatePyproject)},
+}
+
+// Register adds an updater for the named type of file. Files whose base name matches one of the
+// patterns use it unless their type is given explicitly.
+func Register(name string, updater Updater, patterns ...string) {
+	updaters[name] = &registration{patterns: patterns, updater: updater}
+}
+
+// Types returns the names of the registered types in alphabetical order
+func Types() []string {
+	names := []string{}
+	for name := range updaters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the updater for a file, either the one of the named type or, when name is empty,
+// the one whose pattern matches the file name
+func Get(name string, path string) (Updater, error) {
+	if name != "" {
+		registration, ok := updaters[name]
+		if !ok {
+			return nil, errors.Errorf("unknown file type '%s', expected one of %s", name, strings.Join(Types(), ", "))
+		}
+		return registration.updater, nil
+	}
+
+	base := filepath.Base(path)
+	for _, name := range Types() {
+		for _, pattern := range updaters[name].patterns {
+			if matched, _ := filepath.Match(pattern, base); matched {
+				return updaters[name].updater, nil
+			}
+		}
+	}
+
+	return nil, errors.Errorf("cannot detect the type of '%s', set one of %s", path, strings.Join(Types(), ", "))
+}
+
+// Result is the outcome of updating one file
+type Result struct {
+	Path    string
+	Changed bool
+}
+
+// Apply sets the version in every file, whose paths are relative to root. Every file is updated in
+// memory before any is written so that a file that cannot be updated leaves all of them untouched.
+// When check is true no files are written and the results report which files are out of date.
+func Apply(root string, files []git.UpdateFile, version string, check bool) ([]*Result, error) {
+	type update struct {
+		path    string
+		content []byte
+		mode    os.FileMode
+	}
+
+	results := []*Result{}
+	updates := []*update{}
+	for _, file := range files {
+		updater, err := Get(file.Type, file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(root, file.Path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot update %s", file.Path)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot update %s", file.Path)
+		}
+
+		updated, err := updater.Update(content, version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot update %s", file.Path)
+		}
+
+		changed := string(updated) != string(content)
+		results = append(results, &Result{Path: file.Path, Changed: changed})
+		if changed {
+			updates = append(updates, &update{path: path, content: updated, mode: info.Mode()})
+		}
+	}
+
+	if check {
+		return results, nil
+	}
+
+	for _, u := range updates {
+		err := ioutil.WriteFile(u.path, u.content, u.mode)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot write %s", u.path)
+		}
+	}
+
+	return results, nil
+}
+
+// replace returns content with the bytes between start and end replaced by value
+func replace(content []byte, start int, end int, value string) []byte {
+	result := make([]byte, 0, len(content)-(end-start)+len(value))
+	result = append(result, content[:start]...)
+	result = append(result, value...)
+	return append(result, content[end:]...)
+}
@@ -0,0 +1,233 @@
+package updater_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/syncromatics/gogitver/pkg/git"
+	"github.com/syncromatics/gogitver/pkg/updater"
+)
+
+func update(t *testing.T, path string, content string) string {
+	u, err := updater.Get("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := u.Update([]byte(content), "1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(updated)
+}
+
+func TestUpdatePackageJSON(t *testing.T) {
+	content := `{
+  "name": "app",
+  "version":  "0.1.0",
+  "dependencies": {
+    "left-pad": { "version": "1.0.0" }
+  }
+}
+`
+
+	assert.Equal(t, `{
+  "name": "app",
+  "version":  "1.3.0",
+  "dependencies": {
+    "left-pad": { "version": "1.0.0" }
+  }
+}
+`, update(t, "web/package.json", content))
+}
+
+func TestUpdatePackageJSONSkipsNestedVersions(t *testing.T) {
+	content := `{"engines": {"version": "1"}, "scripts": ["version"], "version": "0.1.0"}`
+
+	assert.Equal(t, `{"engines": {"version": "1"}, "scripts": ["version"], "version": "1.3.0"}`, update(t, "package.json", content))
+}
+
+func TestUpdateChart(t *testing.T) {
+	content := `apiVersion: v2
+name: app
+version: 0.1.0 # the chart version
+appVersion: "0.1.0"
+dependencies:
+  - name: redis
+    version: 10.0.0
+`
+
+	assert.Equal(t, `apiVersion: v2
+name: app
+version: 1.3.0 # the chart version
+appVersion: "1.3.0"
+dependencies:
+  - name: redis
+    version: 10.0.0
+`, update(t, "charts/app/Chart.yaml", content))
+}
+
+func TestUpdateCsproj(t *testing.T) {
+	content := `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>netstandard2.0</TargetFramework>
+    <Version>0.1.0</Version>
+  </PropertyGroup>
+</Project>
+`
+
+	assert.Equal(t, `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>netstandard2.0</TargetFramework>
+    <Version>1.3.0</Version>
+  </PropertyGroup>
+</Project>
+`, update(t, "src/App/App.csproj", content))
+}
+
+func TestUpdateCsprojWithEmptyVersion(t *testing.T) {
+	content := `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <Version />
+    <TargetFramework>netstandard2.0</TargetFramework>
+  </PropertyGroup>
+</Project>
+`
+
+	assert.Equal(t, `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <Version>1.3.0</Version>
+    <TargetFramework>netstandard2.0</TargetFramework>
+  </PropertyGroup>
+</Project>
+`, update(t, "src/App/App.csproj", content))
+}
+
+func TestUpdatePom(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent>
+    <groupId>org.example</groupId>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>0.1.0-SNAPSHOT</version>
+  <dependencies>
+    <dependency><version>3.0.0</version></dependency>
+  </dependencies>
+</project>
+`
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent>
+    <groupId>org.example</groupId>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>1.3.0</version>
+  <dependencies>
+    <dependency><version>3.0.0</version></dependency>
+  </dependencies>
+</project>
+`, update(t, "pom.xml", content))
+}
+
+func TestUpdateCargo(t *testing.T) {
+	content := `[package]
+name = "app"
+version = "0.1.0"  # bumped by gogitver
+
+[dependencies]
+serde = { version = "1.0" }
+`
+
+	assert.Equal(t, `[package]
+name = "app"
+version = "1.3.0"  # bumped by gogitver
+
+[dependencies]
+serde = { version = "1.0" }
+`, update(t, "Cargo.toml", content))
+}
+
+func TestUpdatePyproject(t *testing.T) {
+	content := `[build-system]
+requires = ["poetry-core"]
+
+[tool.poetry]
+name = "app"
+version = '0.1.0'
+`
+
+	assert.Equal(t, `[build-system]
+requires = ["poetry-core"]
+
+[tool.poetry]
+name = "app"
+version = '1.3.0'
+`, update(t, "pyproject.toml", content))
+}
+
+func TestUpdateFailsWithoutVersion(t *testing.T) {
+	u, err := updater.Get("cargo", "Cargo.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = u.Update([]byte("[workspace]\nmembers = [\"app\"]\n"), "1.3.0")
+
+	assert.EqualError(t, err, "no version key in [package]")
+}
+
+func TestGetFailsForUnknownFile(t *testing.T) {
+	_, err := updater.Get("", "VERSION")
+
+	assert.EqualError(t, err, "cannot detect the type of 'VERSION', set one of cargo, helm, maven, msbuild, npm, pyproject")
+}
+
+func TestRegisterAddsUpdater(t *testing.T) {
+	updater.Register("version-file", updater.UpdaterFunc(func(content []byte, version string) ([]byte, error) {
+		return []byte(version + "\n"), nil
+	}), "VERSION")
+
+	assert.Equal(t, "1.3.0\n", update(t, "VERSION", "0.1.0\n"))
+}
+
+func TestApply(t *testing.T) {
+	root, err := ioutil.TempDir("", "gogitver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	err = ioutil.WriteFile(filepath.Join(root, "package.json"), []byte(`{"version": "0.1.0"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]\nversion = \"1.3.0\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []git.UpdateFile{{Path: "package.json"}, {Path: "Cargo.toml", Type: "cargo"}}
+
+	results, err := updater.Apply(root, files, "1.3.0", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []*updater.Result{{Path: "package.json", Changed: true}, {Path: "Cargo.toml", Changed: false}}, results)
+
+	content, err := ioutil.ReadFile(filepath.Join(root, "package.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"version": "0.1.0"}`, string(content))
+
+	_, err = updater.Apply(root, files, "1.3.0", false)
+	assert.Nil(t, err)
+
+	content, err = ioutil.ReadFile(filepath.Join(root, "package.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"version": "1.3.0"}`, string(content))
+}
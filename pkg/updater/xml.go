@@ -0,0 +1,65 @@
+package updater
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// updateMSBuild sets the Version property of an MSBuild project such as a csproj
+func updateMSBuild(content []byte, version string) ([]byte, error) {
+	return updateXMLElement(content, []string{"Project", "PropertyGroup", "Version"}, version)
+}
+
+// updatePom sets the version of a maven project, leaving the versions of its parent and dependencies alone
+func updatePom(content []byte, version string) ([]byte, error) {
+	return updateXMLElement(content, []string{"project", "version"}, version)
+}
+
+// updateXMLElement replaces the text of the first element at path, expanding the element when it
+// is self-closing
+func updateXMLElement(content []byte, path []string, version string) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	stack := []string{}
+	open, start := -1, -1
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid xml")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if start < 0 && strings.Join(stack, "/") == strings.Join(path, "/") {
+				open, start = offset, int(decoder.InputOffset())
+			}
+		case xml.EndElement:
+			if start >= 0 && strings.Join(stack, "/") == strings.Join(path, "/") {
+				if offset == start {
+					return replace(content, open, start, expandElement(content[open:start], version)), nil
+				}
+				return replace(content, start, offset, version), nil
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return nil, errors.Errorf("no <%s> element", strings.Join(path, "/"))
+}
+
+// expandElement returns a self-closing element as a start tag, its text and an end tag
+func expandElement(element []byte, text string) string {
+	tag := strings.TrimRight(strings.TrimSuffix(string(element), "/>"), " \t\r\n")
+	name := strings.FieldsFunc(tag[1:], func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	})[0]
+	return tag + ">" + text + "</" + name + ">"
+}
@@ -0,0 +1,37 @@
+package updater
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// updateChart sets the version of a helm Chart.yaml and, when it has one, its appVersion
+func updateChart(content []byte, version string) ([]byte, error) {
+	updated, found := replaceYAMLScalar(content, "version", version)
+	if !found {
+		return nil, errors.New("no top level version key")
+	}
+
+	updated, _ = replaceYAMLScalar(updated, "appVersion", version)
+	return updated, nil
+}
+
+// replaceYAMLScalar replaces the value of a top level key whose value is a plain or quoted scalar,
+// keeping its quotes and any comment after it.
+func replaceYAMLScalar(content []byte, key string, value string) ([]byte, bool) {
+	pattern := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:[ \t]*(?:"([^"\n]*)"|'([^'\n]*)'|([^\s#"']+))`)
+	match := pattern.FindSubmatchIndex(content)
+	if match == nil {
+		return content, false
+	}
+
+	for group := 1; group <= 3; group++ {
+		start, end := match[group*2], match[group*2+1]
+		if start >= 0 {
+			return replace(content, start, end, value), true
+		}
+	}
+
+	return content, false
+}